@@ -6,15 +6,19 @@ import (
 	"io"
 	"os"
 	"runtime/debug"
-	"time"
 
 	"github.com/ad/anonstickerbot/config"
+	"github.com/ad/anonstickerbot/lifecycle"
 	"github.com/ad/anonstickerbot/logger"
 	sndr "github.com/ad/anonstickerbot/sender"
 	su "github.com/ad/anonstickerbot/stickerUpdater"
 )
 
 func Run(ctx context.Context, w io.Writer, args []string) error {
+	if len(args) > 1 && args[1] == "render" {
+		return Render(ctx, w, args[1:])
+	}
+
 	conf, errInitConfig := config.InitConfig(os.Args)
 	if errInitConfig != nil {
 		return errInitConfig
@@ -39,34 +43,38 @@ func Run(ctx context.Context, w io.Writer, args []string) error {
 		return err
 	}
 
-	if len(conf.TelegramAdminIDsList) != 0 {
-		sender.MakeRequestDeferred(sndr.DeferredMessage{
-			Method: "sendMessage",
-			ChatID: conf.TelegramAdminIDsList[0],
-			Text:   "Bot restarted: " + me.Username,
-		}, sender.SendResult)
-	}
-
 	stickerUpdater, err := su.InitStickerUpdater(lgr, conf, sender.Bot, sender)
 	if err != nil {
 		return err
 	}
 
-	err = stickerUpdater.RunAll()
-	if err != nil {
-		fmt.Println(err)
-	}
+	sender.Username = me.Username
+	sender.Renderer = stickerUpdater
+	stickerUpdater.RegisterCommands()
 
-	updateTicker := time.NewTicker(time.Duration(conf.UPDATE_DELAY) * time.Second)
-
-	go func() {
-		for range updateTicker.C {
-			err = stickerUpdater.RunAll()
-			if err != nil {
-				fmt.Println(err)
+	notifyAdmins := func(text string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			if len(conf.TelegramAdminIDsList) != 0 {
+				sender.MakeRequestDeferred(sndr.DeferredMessage{
+					Method: "sendMessage",
+					ChatID: conf.TelegramAdminIDsList[0],
+					Text:   text + me.Username,
+				}, sender.SendResult)
 			}
+
+			return nil
 		}
-	}()
+	}
+
+	// stopped in reverse order: updates finish first, then polling stops and
+	// the queue is flushed together with the stopping notice
+	manager := lifecycle.NewManager(lgr, conf.SHUTDOWN_TIMEOUT)
+	manager.Register(
+		sender,
+		lifecycle.Hook("admin notice", notifyAdmins("Bot restarted: "), notifyAdmins("Bot stopping: ")),
+		lifecycle.Background("bot poller", sender.Bot.Start),
+		stickerUpdater,
+	)
 
-	return nil
+	return manager.Run(ctx)
 }
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ad/anonstickerbot/config"
+	"github.com/ad/anonstickerbot/logger"
+	su "github.com/ad/anonstickerbot/stickerUpdater"
+)
+
+// Render writes a preview of a sticker to a local file without a bot token:
+//
+//	anonstickerbot render -token anon -out anon.png [-fixture data.json]
+//
+// The format is taken from the -out extension: png, webp or webm.
+func Render(ctx context.Context, w io.Writer, args []string) error {
+	conf, err := config.InitConfig(args[:1])
+	if err != nil {
+		return err
+	}
+
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(w)
+
+	token := flags.String("token", "", "sticker name or token directory")
+	out := flags.String("out", "", "output file, .png, .webp or .webm")
+	fixturePath := flags.String("fixture", "", "JSON file with market data to render instead of fetching it")
+	flags.StringVar(&conf.TOKENS_PATH, "tokensPath", conf.TOKENS_PATH, "TOKENS_PATH")
+	flags.StringVar(&conf.THEME, "theme", conf.THEME, "THEME")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if *token == "" || *out == "" {
+		flags.Usage()
+		return fmt.Errorf("render: -token and -out are required")
+	}
+
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(*out), "."))
+
+	var fixture *su.Fixture
+	if *fixturePath != "" {
+		if fixture, err = su.LoadFixture(*fixturePath); err != nil {
+			return err
+		}
+	}
+
+	stickerUpdater, err := su.InitStickerUpdater(logger.InitLogger(conf.Debug), conf, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := config.WithTimeout(ctx, conf.UPDATE_TIMEOUT)
+	defer cancel()
+
+	data, err := stickerUpdater.Preview(ctx, *token, fixture, format)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%s: %d bytes\n", *out, len(data))
+
+	return nil
+}
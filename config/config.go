@@ -22,12 +22,49 @@ type Config struct {
 	TelegramTargetChat   string  `json:"TELEGRAM_TARGET_CHAT"`
 	TelegramTargetChatID int64   `json:"-"`
 
-	TOKENS_PATH string `json:"TOKENS_PATH"`
+	INLINE_PINNED    string   `json:"INLINE_PINNED"` // sticker names shown first in inline results
+	InlinePinnedList []string `json:"-"`
 
+	ON_DEMAND_CACHE_TTL   int `json:"ON_DEMAND_CACHE_TTL"`
+	ON_DEMAND_RATE_LIMIT  int `json:"ON_DEMAND_RATE_LIMIT"` // new stickers a user may render per window, 0 turns rendering off
+	ON_DEMAND_RATE_WINDOW int `json:"ON_DEMAND_RATE_WINDOW"`
+
+	CHART_RATE_LIMIT  int `json:"CHART_RATE_LIMIT"` // uncached /chart fetches a user may start per window, 0 is no limit
+	CHART_RATE_WINDOW int `json:"CHART_RATE_WINDOW"`
+
+	TOKENS_PATH   string `json:"TOKENS_PATH"`
+	TOKENS_RELOAD int    `json:"TOKENS_RELOAD"`
+	FONTS_PATH    string `json:"FONTS_PATH"`
+
+	WEBP_ENCODER string `json:"WEBP_ENCODER"`
+	FFMPEG_PATH  string `json:"FFMPEG_PATH"`
+	THEME        string `json:"THEME"`
+
+	LOCALE       string           `json:"LOCALE"`
+	CHAT_LOCALES string           `json:"CHAT_LOCALES"` // chat:locale pairs, e.g. -100123:ru,456:en
+	ChatLocales  map[int64]string `json:"-"`
+	TIMEZONE     string           `json:"TIMEZONE"`
+
+	SVG_CONVERT_PATH string `json:"SVG_CONVERT_PATH"`
+
+	DATA_PROVIDER  string `json:"DATA_PROVIDER"`
 	DATA_URL       string `json:"DATA_URL"`
 	DATA_OHLCV_URL string `json:"DATA_OHLCV_URL"`
 
-	UPDATE_DELAY int `json:"UPDATE_DELAY"`
+	DEXSCREENER_URL string `json:"DEXSCREENER_URL"`
+
+	PROVIDER_COOLDOWN int `json:"PROVIDER_COOLDOWN"`
+
+	HTTP_TIMEOUT     int `json:"HTTP_TIMEOUT"`
+	TELEGRAM_TIMEOUT int `json:"TELEGRAM_TIMEOUT"`
+
+	UPDATE_DELAY       int `json:"UPDATE_DELAY"`
+	UPDATE_JITTER      int `json:"UPDATE_JITTER"`
+	UPDATE_MAX_BACKOFF int `json:"UPDATE_MAX_BACKOFF"`
+	UPDATE_CONCURRENCY int `json:"UPDATE_CONCURRENCY"`
+	UPDATE_TIMEOUT     int `json:"UPDATE_TIMEOUT"`
+
+	SHUTDOWN_TIMEOUT int `json:"SHUTDOWN_TIMEOUT"`
 
 	Debug bool `json:"DEBUG"`
 }
@@ -37,6 +74,40 @@ func InitConfig(args []string) (*Config, error) {
 		TelegramToken:        "",
 		TelegramAdminIDs:     "",
 		TelegramAdminIDsList: []int64{},
+		InlinePinnedList:     []string{},
+
+		ON_DEMAND_CACHE_TTL:   300,
+		ON_DEMAND_RATE_LIMIT:  5,
+		ON_DEMAND_RATE_WINDOW: 600,
+
+		CHART_RATE_LIMIT:  10,
+		CHART_RATE_WINDOW: 600,
+
+		TOKENS_RELOAD: 10,
+
+		DATA_PROVIDER:     "geckoterminal",
+		PROVIDER_COOLDOWN: 300,
+
+		HTTP_TIMEOUT:     15,
+		TELEGRAM_TIMEOUT: 30,
+
+		UPDATE_DELAY:       60,
+		UPDATE_JITTER:      10,
+		UPDATE_MAX_BACKOFF: 1800,
+		UPDATE_CONCURRENCY: 4,
+		UPDATE_TIMEOUT:     45,
+
+		SHUTDOWN_TIMEOUT: 20,
+
+		WEBP_ENCODER: "cwebp",
+		FFMPEG_PATH:  "ffmpeg",
+		THEME:        "dark",
+
+		LOCALE:      "en",
+		ChatLocales: map[int64]string{},
+		TIMEZONE:    "UTC",
+
+		SVG_CONVERT_PATH: "rsvg-convert",
 
 		Debug: false,
 	}
@@ -60,13 +131,46 @@ func InitConfig(args []string) (*Config, error) {
 		flags.StringVar(&config.TelegramToken, "telegramToken", lookupEnvOrString("TELEGRAM_TOKEN", config.TelegramToken), "TELEGRAM_TOKEN")
 		flags.StringVar(&config.TelegramAdminIDs, "telegramAdminIDs", lookupEnvOrString("TELEGRAM_ADMIN_IDS", config.TelegramAdminIDs), "TELEGRAM_ADMIN_IDS")
 		flags.StringVar(&config.TelegramTargetChat, "telegramTargetChat", lookupEnvOrString("TELEGRAM_TARGET_CHAT", config.TelegramTargetChat), "TELEGRAM_TARGET_CHAT")
+		flags.StringVar(&config.INLINE_PINNED, "inlinePinned", lookupEnvOrString("INLINE_PINNED", config.INLINE_PINNED), "INLINE_PINNED")
+
+		flags.IntVar(&config.ON_DEMAND_CACHE_TTL, "onDemandCacheTtl", lookupEnvOrInt("ON_DEMAND_CACHE_TTL", config.ON_DEMAND_CACHE_TTL), "ON_DEMAND_CACHE_TTL")
+		flags.IntVar(&config.ON_DEMAND_RATE_LIMIT, "onDemandRateLimit", lookupEnvOrInt("ON_DEMAND_RATE_LIMIT", config.ON_DEMAND_RATE_LIMIT), "ON_DEMAND_RATE_LIMIT")
+		flags.IntVar(&config.ON_DEMAND_RATE_WINDOW, "onDemandRateWindow", lookupEnvOrInt("ON_DEMAND_RATE_WINDOW", config.ON_DEMAND_RATE_WINDOW), "ON_DEMAND_RATE_WINDOW")
+
+		flags.IntVar(&config.CHART_RATE_LIMIT, "chartRateLimit", lookupEnvOrInt("CHART_RATE_LIMIT", config.CHART_RATE_LIMIT), "CHART_RATE_LIMIT")
+		flags.IntVar(&config.CHART_RATE_WINDOW, "chartRateWindow", lookupEnvOrInt("CHART_RATE_WINDOW", config.CHART_RATE_WINDOW), "CHART_RATE_WINDOW")
 
 		flags.StringVar(&config.TOKENS_PATH, "tokensPath", lookupEnvOrString("TOKENS_PATH", config.TOKENS_PATH), "TOKENS_PATH")
+		flags.IntVar(&config.TOKENS_RELOAD, "tokensReload", lookupEnvOrInt("TOKENS_RELOAD", config.TOKENS_RELOAD), "TOKENS_RELOAD")
+		flags.StringVar(&config.FONTS_PATH, "fontsPath", lookupEnvOrString("FONTS_PATH", config.FONTS_PATH), "FONTS_PATH")
+
+		flags.StringVar(&config.WEBP_ENCODER, "webpEncoder", lookupEnvOrString("WEBP_ENCODER", config.WEBP_ENCODER), "WEBP_ENCODER")
+		flags.StringVar(&config.FFMPEG_PATH, "ffmpegPath", lookupEnvOrString("FFMPEG_PATH", config.FFMPEG_PATH), "FFMPEG_PATH")
+		flags.StringVar(&config.THEME, "theme", lookupEnvOrString("THEME", config.THEME), "THEME")
+
+		flags.StringVar(&config.LOCALE, "locale", lookupEnvOrString("LOCALE", config.LOCALE), "LOCALE")
+		flags.StringVar(&config.CHAT_LOCALES, "chatLocales", lookupEnvOrString("CHAT_LOCALES", config.CHAT_LOCALES), "CHAT_LOCALES")
+		flags.StringVar(&config.TIMEZONE, "timezone", lookupEnvOrString("TIMEZONE", config.TIMEZONE), "TIMEZONE")
+		flags.StringVar(&config.SVG_CONVERT_PATH, "svgConvertPath", lookupEnvOrString("SVG_CONVERT_PATH", config.SVG_CONVERT_PATH), "SVG_CONVERT_PATH")
 
+		flags.StringVar(&config.DATA_PROVIDER, "dataProvider", lookupEnvOrString("DATA_PROVIDER", config.DATA_PROVIDER), "DATA_PROVIDER")
 		flags.StringVar(&config.DATA_URL, "dataUrl", lookupEnvOrString("DATA_URL", config.DATA_URL), "DATA_URL")
 		flags.StringVar(&config.DATA_OHLCV_URL, "dataOhlcvUrl", lookupEnvOrString("DATA_OHLCV_URL", config.DATA_OHLCV_URL), "DATA_OHLCV_URL")
 
+		flags.StringVar(&config.DEXSCREENER_URL, "dexscreenerUrl", lookupEnvOrString("DEXSCREENER_URL", config.DEXSCREENER_URL), "DEXSCREENER_URL")
+
+		flags.IntVar(&config.PROVIDER_COOLDOWN, "providerCooldown", lookupEnvOrInt("PROVIDER_COOLDOWN", config.PROVIDER_COOLDOWN), "PROVIDER_COOLDOWN")
+
+		flags.IntVar(&config.HTTP_TIMEOUT, "httpTimeout", lookupEnvOrInt("HTTP_TIMEOUT", config.HTTP_TIMEOUT), "HTTP_TIMEOUT")
+		flags.IntVar(&config.TELEGRAM_TIMEOUT, "telegramTimeout", lookupEnvOrInt("TELEGRAM_TIMEOUT", config.TELEGRAM_TIMEOUT), "TELEGRAM_TIMEOUT")
+
 		flags.IntVar(&config.UPDATE_DELAY, "updateDelay", lookupEnvOrInt("UPDATE_DELAY", config.UPDATE_DELAY), "UPDATE_DELAY")
+		flags.IntVar(&config.UPDATE_JITTER, "updateJitter", lookupEnvOrInt("UPDATE_JITTER", config.UPDATE_JITTER), "UPDATE_JITTER")
+		flags.IntVar(&config.UPDATE_MAX_BACKOFF, "updateMaxBackoff", lookupEnvOrInt("UPDATE_MAX_BACKOFF", config.UPDATE_MAX_BACKOFF), "UPDATE_MAX_BACKOFF")
+		flags.IntVar(&config.UPDATE_CONCURRENCY, "updateConcurrency", lookupEnvOrInt("UPDATE_CONCURRENCY", config.UPDATE_CONCURRENCY), "UPDATE_CONCURRENCY")
+		flags.IntVar(&config.UPDATE_TIMEOUT, "updateTimeout", lookupEnvOrInt("UPDATE_TIMEOUT", config.UPDATE_TIMEOUT), "UPDATE_TIMEOUT")
+
+		flags.IntVar(&config.SHUTDOWN_TIMEOUT, "shutdownTimeout", lookupEnvOrInt("SHUTDOWN_TIMEOUT", config.SHUTDOWN_TIMEOUT), "SHUTDOWN_TIMEOUT")
 
 		flags.BoolVar(&config.Debug, "debug", lookupEnvOrBool("DEBUG", config.Debug), "Debug")
 
@@ -84,6 +188,27 @@ func InitConfig(args []string) (*Config, error) {
 		}
 	}
 
+	if config.INLINE_PINNED != "" {
+		for _, name := range strings.Split(config.INLINE_PINNED, ",") {
+			if name = strings.Trim(name, "\n\t "); name != "" {
+				config.InlinePinnedList = append(config.InlinePinnedList, name)
+			}
+		}
+	}
+
+	if config.CHAT_LOCALES != "" {
+		for _, pair := range strings.Split(config.CHAT_LOCALES, ",") {
+			chatID, locale, ok := strings.Cut(strings.Trim(pair, "\n\t "), ":")
+			if !ok {
+				continue
+			}
+
+			if chatIDInt, err := strconv.ParseInt(chatID, 10, 64); err == nil {
+				config.ChatLocales[chatIDInt] = strings.TrimSpace(locale)
+			}
+		}
+	}
+
 	if config.TelegramTargetChat != "" {
 		if chatIDInt, err := strconv.ParseInt(strings.Trim(config.TelegramTargetChat, "\n\t "), 10, 64); err == nil {
 			config.TelegramTargetChatID = chatIDInt
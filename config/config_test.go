@@ -39,7 +39,20 @@ func TestInitConfig(t *testing.T) {
 		t.Errorf("Expected TelegramAdmin to be '7890', but got '%s'", config.TelegramAdminIDs)
 	}
 
+	// Test case 4: Chat locales
+	os.Setenv("CHAT_LOCALES", "-100123:ru, 456:en,broken")
+
+	config, err = InitConfig([]string{""})
+	if err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+
+	if len(config.ChatLocales) != 2 || config.ChatLocales[-100123] != "ru" || config.ChatLocales[456] != "en" {
+		t.Errorf("Expected ru and en chat locales, but got %v", config.ChatLocales)
+	}
+
 	// Clean up environment variables
+	os.Unsetenv("CHAT_LOCALES")
 	os.Unsetenv("TELEGRAM_TOKEN")
 	os.Unsetenv("TELEGRAM_ADMIN_ID")
 }
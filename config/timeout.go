@@ -0,0 +1,16 @@
+package config
+
+import (
+	"context"
+	"time"
+)
+
+// WithTimeout limits ctx to a timeout in seconds taken from the config, 0 or
+// less means no deadline.
+func WithTimeout(ctx context.Context, seconds int) (context.Context, context.CancelFunc) {
+	if seconds <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, time.Duration(seconds)*time.Second)
+}
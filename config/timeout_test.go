@@ -0,0 +1,22 @@
+package config
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithTimeout(t *testing.T) {
+	ctx, cancel := WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	if _, ok := ctx.Deadline(); ok || ctx.Err() != nil {
+		t.Errorf("Expected no deadline for 0, but got %v", ctx.Err())
+	}
+
+	ctx, cancel = WithTimeout(context.Background(), 5)
+	defer cancel()
+
+	if _, ok := ctx.Deadline(); !ok {
+		t.Errorf("Expected a deadline for 5 seconds")
+	}
+}
@@ -0,0 +1,76 @@
+package lifecycle
+
+import (
+	"context"
+)
+
+type background struct {
+	name   string
+	run    func(ctx context.Context)
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Background wraps a blocking function that runs until its context is cancelled.
+func Background(name string, run func(ctx context.Context)) Component {
+	return &background{name: name, run: run}
+}
+
+func (b *background) Name() string {
+	return b.name
+}
+
+func (b *background) Start(ctx context.Context) error {
+	ctx, b.cancel = context.WithCancel(ctx)
+	b.done = make(chan struct{})
+
+	go func() {
+		defer close(b.done)
+		b.run(ctx)
+	}()
+
+	return nil
+}
+
+func (b *background) Stop(ctx context.Context) error {
+	b.cancel()
+
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type hook struct {
+	name    string
+	onStart func(ctx context.Context) error
+	onStop  func(ctx context.Context) error
+}
+
+// Hook runs onStart and onStop at the position of the component in the start
+// and stop order. Either of them may be nil.
+func Hook(name string, onStart, onStop func(ctx context.Context) error) Component {
+	return &hook{name: name, onStart: onStart, onStop: onStop}
+}
+
+func (h *hook) Name() string {
+	return h.name
+}
+
+func (h *hook) Start(ctx context.Context) error {
+	if h.onStart == nil {
+		return nil
+	}
+
+	return h.onStart(ctx)
+}
+
+func (h *hook) Stop(ctx context.Context) error {
+	if h.onStop == nil {
+		return nil
+	}
+
+	return h.onStop(ctx)
+}
@@ -0,0 +1,99 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/ad/anonstickerbot/config"
+)
+
+// Component is a long running part of the process. Start must not block,
+// Stop must return once the component has finished its work or ctx is done.
+type Component interface {
+	Name() string
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// Manager starts components in registration order and stops them in reverse.
+type Manager struct {
+	logger          *slog.Logger
+	shutdownTimeout int // seconds, 0 or less waits for the components without a deadline
+	components      []Component
+	started         []Component
+	cancel          context.CancelFunc
+}
+
+func NewManager(logger *slog.Logger, shutdownTimeout int) *Manager {
+	return &Manager{
+		logger:          logger,
+		shutdownTimeout: shutdownTimeout,
+	}
+}
+
+func (m *Manager) Register(components ...Component) {
+	m.components = append(m.components, components...)
+}
+
+// Start starts every registered component. Components get a context that is
+// not cancelled together with ctx, they are expected to finish in Stop instead.
+// If a component fails to start, the already started ones are stopped.
+func (m *Manager) Start(ctx context.Context) error {
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	m.cancel = cancel
+
+	for _, component := range m.components {
+		m.logger.Debug(fmt.Sprintf("starting %s", component.Name()))
+
+		if err := component.Start(runCtx); err != nil {
+			startErr := fmt.Errorf("start %s: %w", component.Name(), err)
+
+			return errors.Join(startErr, m.Stop())
+		}
+
+		m.started = append(m.started, component)
+	}
+
+	return nil
+}
+
+// Stop stops started components in reverse order within the shutdown timeout.
+func (m *Manager) Stop() error {
+	ctx, cancel := config.WithTimeout(context.Background(), m.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+
+	for i := len(m.started) - 1; i >= 0; i-- {
+		component := m.started[i]
+
+		m.logger.Debug(fmt.Sprintf("stopping %s", component.Name()))
+
+		if err := component.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %w", component.Name(), err))
+		}
+	}
+
+	m.started = nil
+
+	if m.cancel != nil {
+		m.cancel()
+	}
+
+	return errors.Join(errs...)
+}
+
+// Run starts the components, waits for ctx to be done and stops them.
+func (m *Manager) Run(ctx context.Context) error {
+	if err := m.Start(ctx); err != nil {
+		return err
+	}
+
+	<-ctx.Done()
+
+	m.logger.Info("shutting down")
+
+	return m.Stop()
+}
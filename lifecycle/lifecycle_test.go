@@ -0,0 +1,99 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestManagerOrder(t *testing.T) {
+	var calls []string
+
+	record := func(call string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			calls = append(calls, call)
+			return nil
+		}
+	}
+
+	manager := NewManager(slog.Default(), 1)
+	manager.Register(
+		Hook("first", record("start first"), record("stop first")),
+		Hook("second", record("start second"), record("stop second")),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := manager.Run(ctx); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	expected := []string{"start first", "start second", "stop second", "stop first"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected %v, but got %v", expected, calls)
+	}
+}
+
+func TestManagerStartFailure(t *testing.T) {
+	stopped := false
+
+	manager := NewManager(slog.Default(), 1)
+	manager.Register(
+		Hook("first", nil, func(ctx context.Context) error {
+			stopped = true
+			return nil
+		}),
+		Hook("broken", func(ctx context.Context) error {
+			return errors.New("boom")
+		}, nil),
+	)
+
+	if err := manager.Start(context.Background()); err == nil {
+		t.Errorf("Expected start error, but got nil")
+	}
+
+	if !stopped {
+		t.Errorf("Expected started components to be stopped after a failure")
+	}
+}
+
+func TestManagerStopWithoutTimeout(t *testing.T) {
+	var stopErr error
+
+	manager := NewManager(slog.Default(), 0)
+	manager.Register(Hook("first", nil, func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); ok || ctx.Err() != nil {
+			stopErr = errors.New("the stop context is limited")
+		}
+		return nil
+	}))
+
+	if err := manager.Start(context.Background()); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if err := manager.Stop(); err != nil || stopErr != nil {
+		t.Errorf("Expected no deadline with a zero timeout, but got %v and %v", err, stopErr)
+	}
+}
+
+func TestBackgroundStop(t *testing.T) {
+	component := Background("loop", func(ctx context.Context) {
+		<-ctx.Done()
+	})
+
+	if err := component.Start(context.Background()); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := component.Stop(ctx); err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+}
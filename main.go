@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	_ "time/tzdata" // TIMEZONE and token time zones in the scratch image
 
 	"github.com/ad/anonstickerbot/app"
 )
@@ -17,28 +18,14 @@ var (
 func main() {
 	fmt.Printf("starting version %s\n", version)
 
-	sigs := make(chan os.Signal, 1)
-
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-
-	done := make(chan bool, 1)
-
-	ctx := context.Background()
-	ctx, cancel := signal.NotifyContext(ctx, os.Interrupt)
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
+	// Run blocks until a signal is received and every component has stopped
 	if err := app.Run(ctx, os.Stdout, os.Args); err != nil {
 		fmt.Fprintf(os.Stderr, "%s\n", err)
 		os.Exit(1)
 	}
 
-	go func() {
-		sig := <-sigs
-		fmt.Println(sig)
-		cancel()
-		done <- true
-	}()
-
-	<-done
 	fmt.Println("exiting")
 }
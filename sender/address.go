@@ -0,0 +1,75 @@
+package sender
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+const (
+	tonAddressBounceable = 0x11
+	tonAddressTestnet    = 0x80
+)
+
+// ParseTONAddress recognizes a TON address in the user-friendly (EQ..., UQ...)
+// or raw (0:hex) form and returns it user-friendly, bounceable and URL safe,
+// the way explorers and market data providers show it.
+func ParseTONAddress(address string) (string, bool) {
+	address = strings.TrimSpace(address)
+
+	var data [36]byte
+
+	if workchain, hash, ok := strings.Cut(address, ":"); ok {
+		id, err := strconv.ParseInt(workchain, 10, 8)
+		if err != nil || len(hash) != 64 {
+			return "", false
+		}
+
+		if _, err := hex.Decode(data[2:34], []byte(hash)); err != nil {
+			return "", false
+		}
+
+		data[1] = byte(int8(id))
+	} else {
+		if len(address) != 48 {
+			return "", false
+		}
+
+		// both base64 alphabets are in use
+		decoded, err := base64.RawURLEncoding.DecodeString(strings.NewReplacer("+", "-", "/", "_").Replace(address))
+		if err != nil || len(decoded) != len(data) {
+			return "", false
+		}
+
+		if crc16(decoded[:34]) != binary.BigEndian.Uint16(decoded[34:]) || decoded[0]&tonAddressTestnet != 0 {
+			return "", false
+		}
+
+		copy(data[:], decoded)
+	}
+
+	data[0] = tonAddressBounceable
+	binary.BigEndian.PutUint16(data[34:], crc16(data[:34]))
+
+	return base64.RawURLEncoding.EncodeToString(data[:]), true
+}
+
+// crc16 is CRC-16/XMODEM, the checksum of user-friendly addresses.
+func crc16(data []byte) uint16 {
+	var crc uint16
+
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
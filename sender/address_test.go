@@ -0,0 +1,31 @@
+package sender
+
+import "testing"
+
+func TestParseTONAddress(t *testing.T) {
+	const anon = "EQAjeq_aW_fSP7XqoF15ZZ7zUYiWLqv6UccN-jJlliomy-B3"
+
+	tests := []struct {
+		address  string
+		expected string
+	}{
+		{anon, anon},
+		{" " + anon + "\n", anon},
+		{"UQAjeq_aW_fSP7XqoF15ZZ7zUYiWLqv6UccN-jJlliomy72y", anon}, // non-bounceable
+		{"UQAjeq/aW/fSP7XqoF15ZZ7zUYiWLqv6UccN+jJlliomy72y", anon}, // standard base64
+		{"0:237aafda5bf7d23fb5eaa05d79659ef35188962eabfa51c70dfa3265962a26cb", anon},
+		{"EQAjeq_aW_fSP7XqoF15ZZ7zUYiWLqv6UccN-jJlliomy-B4", ""}, // checksum
+		{"EQAjeq_aW_fSP7XqoF15ZZ7zUYiWLqv6UccN-jJlliomy", ""},
+		{"0:237aafda5bf7d23fb5eaa05d79659ef35188962eabfa51c70dfa3265962a26", ""},
+		{"x:237aafda5bf7d23fb5eaa05d79659ef35188962eabfa51c70dfa3265962a26cb", ""},
+		{"anon", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		result, ok := ParseTONAddress(test.address)
+		if ok != (test.expected != "") || result != test.expected {
+			t.Errorf("%q: expected %q, but got %q", test.address, test.expected, result)
+		}
+	}
+}
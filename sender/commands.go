@@ -0,0 +1,68 @@
+package sender
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// CommandHandler answers a chat command, args are the words after it.
+type CommandHandler func(ctx context.Context, b *bot.Bot, message *models.Message, args []string)
+
+// RegisterCommand routes /name messages in private chats and groups to
+// handler. Commands are registered before polling starts.
+func (s *Sender) RegisterCommand(name string, handler CommandHandler) {
+	s.commands[strings.ToLower(name)] = handler
+}
+
+// RegisterAdminCommand routes /name to handler for TELEGRAM_ADMIN_IDS only,
+// other users get no answer.
+func (s *Sender) RegisterAdminCommand(name string, handler CommandHandler) {
+	s.RegisterCommand(name, func(ctx context.Context, b *bot.Bot, message *models.Message, args []string) {
+		if message.From == nil || !slices.Contains(s.config.TelegramAdminIDsList, message.From.ID) {
+			return
+		}
+
+		handler(ctx, b, message, args)
+	})
+}
+
+func (s *Sender) handleCommand(ctx context.Context, b *bot.Bot, message *models.Message) {
+	// commands may come in the caption of a file
+	name, args, ok := parseCommand(cmp.Or(message.Text, message.Caption), s.Username)
+	if !ok {
+		return
+	}
+
+	if handler, ok := s.commands[name]; ok {
+		handler(ctx, b, message, args)
+	}
+}
+
+// parseCommand splits "/name@bot args" into the lowercase name and the args.
+// Commands addressed to another bot in a group are not ours.
+func parseCommand(text, username string) (string, []string, bool) {
+	if !strings.HasPrefix(text, "/") {
+		return "", nil, false
+	}
+
+	fields := strings.Fields(text[1:])
+	if len(fields) == 0 {
+		return "", nil, false
+	}
+
+	name, addressee, addressed := strings.Cut(fields[0], "@")
+	if addressed && !strings.EqualFold(addressee, username) {
+		return "", nil, false
+	}
+
+	if name == "" {
+		return "", nil, false
+	}
+
+	return strings.ToLower(name), fields[1:], true
+}
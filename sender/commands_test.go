@@ -0,0 +1,77 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ad/anonstickerbot/config"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"/price anon", "price [anon]"},
+		{"/Chart  Anon   4h", "chart [Anon 4h]"},
+		{"/stats@CryptoStickerBot anon", "stats [anon]"},
+		{"/stats@otherbot anon", ""},
+		{"/price", "price []"},
+		{"price anon", ""},
+		{"/", ""},
+		{"/@cryptostickerbot", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		name, args, ok := parseCommand(test.text, "cryptostickerbot")
+
+		result := ""
+		if ok {
+			result = fmt.Sprintf("%s %v", name, args)
+		}
+
+		if result != test.expected {
+			t.Errorf("%q: expected %q, but got %q", test.text, test.expected, result)
+		}
+	}
+}
+
+func TestHandleCommand(t *testing.T) {
+	sender, err := InitSender(context.Background(), nil, &config.Config{
+		TelegramToken:        "123:token",
+		TelegramAdminIDsList: []int64{1},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	sender.Username = "cryptostickerbot"
+
+	var handled []string
+
+	sender.RegisterCommand("price", func(ctx context.Context, b *bot.Bot, message *models.Message, args []string) {
+		handled = append(handled, fmt.Sprintf("price %v", args))
+	})
+	sender.RegisterAdminCommand("pause", func(ctx context.Context, b *bot.Bot, message *models.Message, args []string) {
+		handled = append(handled, fmt.Sprintf("pause %d", message.From.ID))
+	})
+
+	for _, message := range []*models.Message{
+		{Text: "/price@CryptoStickerBot anon", From: &models.User{ID: 2}},
+		{Caption: "/PAUSE", From: &models.User{ID: 1}},
+		{Text: "/pause", From: &models.User{ID: 2}},
+		{Text: "/pause"},
+		{Text: "/unknown"},
+	} {
+		sender.handleCommand(context.Background(), nil, message)
+	}
+
+	if result := strings.Join(handled, ", "); result != "price [anon], pause 1" {
+		t.Errorf("Expected price [anon], pause 1, but got %q", result)
+	}
+}
@@ -2,9 +2,12 @@ package sender
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"time"
 
+	"github.com/ad/anonstickerbot/config"
+
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 )
@@ -12,8 +15,13 @@ import (
 const (
 	sendCooldownPerUser = int64(time.Second / 3)
 	sendInterval        = time.Second
+	deferredQueueSize   = 100
 )
 
+// ErrQueueFull is the result of a message dropped because its chat has
+// deferredQueueSize messages waiting.
+var ErrQueueFull = errors.New("deferred messages queue is full")
+
 type DeferredMessage struct {
 	Method string
 
@@ -39,115 +47,202 @@ type SendResult struct {
 	ForwardDate int
 }
 
+// MakeRequestDeferred queues dm to its chat. A message to a chat with a full
+// queue is dropped and reported to callback, the sender is never blocked.
 func (s *Sender) MakeRequestDeferred(dm DeferredMessage, callback func(s SendResult) error) {
 	s.Lock()
-	defer s.Unlock()
-
-	if _, ok := s.deferredMessages[dm.ChatID]; !ok {
-		s.deferredMessages[dm.ChatID] = make(chan DeferredMessage, 100)
+	ch, ok := s.deferredMessages[dm.ChatID]
+	if !ok {
+		ch = make(chan DeferredMessage, deferredQueueSize)
+		s.deferredMessages[dm.ChatID] = ch
 	}
+	s.Unlock()
 
 	dm.callback = callback
 
-	s.deferredMessages[dm.ChatID] <- dm
+	select {
+	case ch <- dm:
+	default:
+		if callback != nil {
+			_ = callback(SendResult{ChatID: dm.ChatID, Msg: dm.Text, Error: ErrQueueFull})
+		}
+	}
 }
 
-func (s *Sender) sendDeferredMessages() {
+// NotifyAdmins queues text to every TELEGRAM_ADMIN_IDS user.
+func (s *Sender) NotifyAdmins(text string) {
+	for _, adminID := range s.config.TelegramAdminIDsList {
+		s.MakeRequestDeferred(DeferredMessage{
+			Method: "sendMessage",
+			ChatID: adminID,
+			Text:   text,
+		}, s.SendResult)
+	}
+}
+
+func (s *Sender) sendDeferredMessages(ctx context.Context) {
 	timer := time.NewTicker(sendInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
 
-	for range timer.C {
 		var cases []reflect.SelectCase
 
+		s.RLock()
 		for userID, ch := range s.deferredMessages {
 			if s.userCanReceiveMessage(userID) && len(ch) > 0 {
 				sc := reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ch)}
 				cases = append(cases, sc)
 			}
 		}
+		s.RUnlock()
 
 		if len(cases) > 0 {
 			_, value, ok := reflect.Select(cases)
 
 			if ok {
-				dm := value.Interface().(DeferredMessage)
-
-				var (
-					err         error
-					messageID   int64
-					forwardDate int
-				)
-
-				switch dm.Method {
-				case "sendMessage":
-					resultMessage, errSendMessage := s.Bot.SendMessage(context.Background(), &bot.SendMessageParams{
-						ChatID:          dm.ChatID,
-						Text:            dm.Text,
-						MessageThreadID: dm.messageThreadID,
-						ReplyParameters: &models.ReplyParameters{MessageID: dm.replyToMessageID},
-						ReplyMarkup:     dm.replyMarkup,
-					})
-
-					messageID = int64(resultMessage.ID)
-					err = errSendMessage
-				case "sendMessageHTML":
-					resultMessage, errSendMessage := s.Bot.SendMessage(context.Background(), &bot.SendMessageParams{
-						ChatID:    dm.ChatID,
-						Text:      dm.Text,
-						ParseMode: "HTML",
-						LinkPreviewOptions: &models.LinkPreviewOptions{
-							IsDisabled: bot.True(),
-						},
-					})
-
-					messageID = int64(resultMessage.ID)
-					err = errSendMessage
-
-				case "copyMessage":
-					resultMessage, errCopyMessage := s.Bot.CopyMessage(context.Background(), &bot.CopyMessageParams{
-						ChatID:          dm.ChatID,
-						FromChatID:      dm.fromChatID,
-						MessageID:       dm.messageID,
-						MessageThreadID: dm.messageThreadID,
-						ReplyParameters: &models.ReplyParameters{MessageID: dm.replyToMessageID},
-					})
-
-					messageID = int64(resultMessage.ID)
-					err = errCopyMessage
-
-				case "forwardMessage":
-					resultMessage, errForwardMessage := s.Bot.ForwardMessage(context.Background(), &bot.ForwardMessageParams{
-						ChatID:     dm.ChatID,
-						FromChatID: dm.fromChatID,
-						MessageID:  dm.messageID,
-					})
-
-					if resultMessage.ForwardOrigin.MessageOriginHiddenUser == nil {
-						forwardDate = resultMessage.ForwardOrigin.MessageOriginUser.Date
-					} else {
-						forwardDate = resultMessage.ForwardOrigin.MessageOriginHiddenUser.Date
-					}
-
-					messageID = int64(resultMessage.ID)
-					err = errForwardMessage
-					dm.Text = resultMessage.Text
-				}
+				// let a message already taken from the queue finish even if
+				// the loop is being stopped
+				s.sendDeferredMessage(context.WithoutCancel(ctx), value.Interface().(DeferredMessage))
+			}
+		}
+	}
+}
+
+// flushDeferredMessages sends everything still queued, respecting the per-chat
+// cooldown, until the queues are empty or ctx is done.
+func (s *Sender) flushDeferredMessages(ctx context.Context) error {
+	s.RLock()
+	queues := make(map[int64]chan DeferredMessage, len(s.deferredMessages))
+	for chatID, ch := range s.deferredMessages {
+		queues[chatID] = ch
+	}
+	s.RUnlock()
+
+	for chatID, ch := range queues {
+		for len(ch) > 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			s.RLock()
+			canSend := s.userCanReceiveMessage(chatID)
+			s.RUnlock()
 
-				if dm.callback != nil {
-					_ = dm.callback(
-						SendResult{
-							ChatID:      dm.ChatID,
-							Msg:         dm.Text,
-							Error:       err,
-							MessageID:   messageID,
-							ForwardDate: forwardDate,
-						},
-					)
+			if !canSend {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(time.Duration(sendCooldownPerUser)):
 				}
 
-				s.lastMessageTimes[dm.ChatID] = time.Now().UnixNano()
+				continue
+			}
+
+			select {
+			case dm := <-ch:
+				s.sendDeferredMessage(ctx, dm)
+			default:
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Sender) sendDeferredMessage(ctx context.Context, dm DeferredMessage) {
+	sendCtx, cancel := config.WithTimeout(ctx, s.config.TELEGRAM_TIMEOUT)
+	defer cancel()
+
+	var (
+		err         error
+		messageID   int64
+		forwardDate int
+	)
+
+	switch dm.Method {
+	case "sendMessage":
+		resultMessage, errSendMessage := s.Bot.SendMessage(sendCtx, &bot.SendMessageParams{
+			ChatID:          dm.ChatID,
+			Text:            dm.Text,
+			MessageThreadID: dm.messageThreadID,
+			ReplyParameters: &models.ReplyParameters{MessageID: dm.replyToMessageID},
+			ReplyMarkup:     dm.replyMarkup,
+		})
+
+		if resultMessage != nil {
+			messageID = int64(resultMessage.ID)
+		}
+		err = errSendMessage
+	case "sendMessageHTML":
+		resultMessage, errSendMessage := s.Bot.SendMessage(sendCtx, &bot.SendMessageParams{
+			ChatID:    dm.ChatID,
+			Text:      dm.Text,
+			ParseMode: "HTML",
+			LinkPreviewOptions: &models.LinkPreviewOptions{
+				IsDisabled: bot.True(),
+			},
+		})
+
+		if resultMessage != nil {
+			messageID = int64(resultMessage.ID)
+		}
+		err = errSendMessage
+
+	case "copyMessage":
+		resultMessage, errCopyMessage := s.Bot.CopyMessage(sendCtx, &bot.CopyMessageParams{
+			ChatID:          dm.ChatID,
+			FromChatID:      dm.fromChatID,
+			MessageID:       dm.messageID,
+			MessageThreadID: dm.messageThreadID,
+			ReplyParameters: &models.ReplyParameters{MessageID: dm.replyToMessageID},
+		})
+
+		if resultMessage != nil {
+			messageID = int64(resultMessage.ID)
+		}
+		err = errCopyMessage
+
+	case "forwardMessage":
+		resultMessage, errForwardMessage := s.Bot.ForwardMessage(sendCtx, &bot.ForwardMessageParams{
+			ChatID:     dm.ChatID,
+			FromChatID: dm.fromChatID,
+			MessageID:  dm.messageID,
+		})
+
+		err = errForwardMessage
+		if resultMessage != nil {
+			if resultMessage.ForwardOrigin.MessageOriginHiddenUser == nil {
+				forwardDate = resultMessage.ForwardOrigin.MessageOriginUser.Date
+			} else {
+				forwardDate = resultMessage.ForwardOrigin.MessageOriginHiddenUser.Date
 			}
+
+			messageID = int64(resultMessage.ID)
+			dm.Text = resultMessage.Text
 		}
 	}
+
+	if dm.callback != nil {
+		_ = dm.callback(
+			SendResult{
+				ChatID:      dm.ChatID,
+				Msg:         dm.Text,
+				Error:       err,
+				MessageID:   messageID,
+				ForwardDate: forwardDate,
+			},
+		)
+	}
+
+	s.Lock()
+	s.lastMessageTimes[dm.ChatID] = time.Now().UnixNano()
+	s.Unlock()
 }
 
 func (s *Sender) userCanReceiveMessage(userID int64) bool {
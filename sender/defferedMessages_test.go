@@ -0,0 +1,31 @@
+package sender
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMakeRequestDeferredFullQueue(t *testing.T) {
+	sender := &Sender{deferredMessages: make(map[int64]chan DeferredMessage)}
+
+	var dropped []error
+
+	for range deferredQueueSize + 2 {
+		sender.MakeRequestDeferred(DeferredMessage{Method: "sendMessage", ChatID: 1, Text: "hi"}, func(result SendResult) error {
+			dropped = append(dropped, result.Error)
+			return result.Error
+		})
+	}
+
+	if len(sender.deferredMessages[1]) != deferredQueueSize {
+		t.Errorf("Expected %d queued messages, but got %d", deferredQueueSize, len(sender.deferredMessages[1]))
+	}
+
+	if len(dropped) != 2 || !errors.Is(dropped[0], ErrQueueFull) {
+		t.Errorf("Expected 2 dropped messages, but got %v", dropped)
+	}
+
+	// the lock isn't held by a blocked producer
+	sender.Lock()
+	sender.Unlock()
+}
@@ -0,0 +1,242 @@
+package sender
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// inlinePageSize is the Telegram limit of results in one inline answer.
+const inlinePageSize = 50
+
+// InlineSticker is a sent sticker offered in inline results.
+type InlineSticker struct {
+	Name    string
+	FileID  string
+	Symbol  string // base token symbol, e.g. ANON
+	Address string
+	Aliases []string
+}
+
+type rankedSticker struct {
+	sticker InlineSticker
+	score   int
+	pinned  int
+}
+
+// answerInlineQuery answers with the stickers matching the query, best
+// matches first, a page at a time.
+func (s *Sender) answerInlineQuery(ctx context.Context, b *bot.Bot, query *models.InlineQuery) {
+	s.RLock()
+	stickers := make([]InlineSticker, 0, len(s.LastStickers))
+	for _, sticker := range s.LastStickers {
+		stickers = append(stickers, sticker)
+	}
+	s.RUnlock()
+
+	// the offset is the number of results already shown
+	offset, _ := strconv.Atoi(query.Offset)
+
+	// addresses are compared in the form stickers have them
+	search := query.Query
+	address, isAddress := ParseTONAddress(search)
+	if isAddress {
+		search = address
+	}
+
+	page, nextOffset := rankStickers(stickers, search, s.config.InlinePinnedList, offset)
+
+	var button *models.InlineQueryResultsButton
+
+	// an address of a token without a sticker is rendered on demand
+	if isAddress && len(page) == 0 && offset == 0 && s.Renderer != nil && s.config.ON_DEMAND_RATE_LIMIT > 0 {
+		waitCtx, cancel := context.WithTimeout(ctx, onDemandWait)
+		sticker, err := s.onDemand.get(waitCtx, query.From.ID, address, s.Renderer.RenderAddress)
+		cancel()
+
+		if err == nil {
+			page = append(page, sticker)
+		} else {
+			button = onDemandErrorButton(err)
+			s.logger.Info(fmt.Sprintf("render %s for %d error: %s", address, query.From.ID, err))
+		}
+	}
+
+	results := make([]models.InlineQueryResult, 0, len(page))
+	for _, sticker := range page {
+		results = append(results, &models.InlineQueryResultCachedSticker{ID: sticker.Name, StickerFileID: sticker.FileID})
+	}
+
+	_, err := b.AnswerInlineQuery(ctx, &bot.AnswerInlineQueryParams{
+		InlineQueryID: query.ID,
+		Results:       results,
+		CacheTime:     0,
+		NextOffset:    nextOffset,
+		Button:        button,
+	})
+
+	if err != nil {
+		fmt.Printf("answer inline query error %s\n", err.Error())
+	}
+}
+
+// onDemandErrorButton tells the user above the empty results why the address
+// has no sticker.
+func onDemandErrorButton(err error) *models.InlineQueryResultsButton {
+	text := "No pool found for this address"
+
+	var rateLimitError *RateLimitError
+
+	switch {
+	case errors.As(err, &rateLimitError):
+		text = fmt.Sprintf("Too many new stickers, try again in %s", rateLimitError.RetryAfter.Round(time.Second))
+	case errors.Is(err, ErrRenderPending):
+		text = "Drawing the sticker, type again in a moment"
+	}
+
+	return &models.InlineQueryResultsButton{Text: text, StartParameter: "address"}
+}
+
+// rankStickers returns the page of stickers matching query starting at
+// offset and the offset of the next page, empty on the last one. Matches are
+// ordered by score, then by the pinned names, then by name.
+func rankStickers(stickers []InlineSticker, query string, pinned []string, offset int) ([]InlineSticker, string) {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	ranked := make([]rankedSticker, 0, len(stickers))
+
+	for _, sticker := range stickers {
+		score := matchSticker(sticker, query)
+		if score == 0 {
+			continue
+		}
+
+		pin := slices.IndexFunc(pinned, func(name string) bool { return strings.EqualFold(name, sticker.Name) })
+		if pin < 0 {
+			pin = len(pinned)
+		}
+
+		ranked = append(ranked, rankedSticker{sticker: sticker, score: score, pinned: pin})
+	}
+
+	slices.SortFunc(ranked, func(a, b rankedSticker) int {
+		return cmp.Or(
+			cmp.Compare(b.score, a.score),
+			cmp.Compare(a.pinned, b.pinned),
+			cmp.Compare(a.sticker.Name, b.sticker.Name),
+		)
+	})
+
+	offset = max(offset, 0)
+	if offset >= len(ranked) {
+		return nil, ""
+	}
+
+	end := min(offset+inlinePageSize, len(ranked))
+
+	page := make([]InlineSticker, 0, end-offset)
+	for _, r := range ranked[offset:end] {
+		page = append(page, r.sticker)
+	}
+
+	nextOffset := ""
+	if end < len(ranked) {
+		nextOffset = strconv.Itoa(end)
+	}
+
+	return page, nextOffset
+}
+
+// matchSticker scores how well the lowercase query matches the name, symbol,
+// aliases or address of the sticker, 0 is no match. Every sticker matches an
+// empty query.
+func matchSticker(sticker InlineSticker, query string) int {
+	if query == "" {
+		return 1
+	}
+
+	best := 0
+
+	for _, field := range append([]string{sticker.Name, sticker.Symbol}, sticker.Aliases...) {
+		best = max(best, matchField(strings.ToLower(field), query, true))
+	}
+
+	// almost any short query is a subsequence of an address
+	best = max(best, matchField(strings.ToLower(sticker.Address), query, false))
+
+	return best
+}
+
+func matchField(field, query string, fuzzy bool) int {
+	switch {
+	case field == "":
+		return 0
+	case field == query:
+		return 100
+	case strings.HasPrefix(field, query):
+		return 80
+	case wordPrefix(field, query):
+		return 70
+	case strings.Contains(field, query):
+		return 60
+	}
+
+	if !fuzzy {
+		return 0
+	}
+
+	// fuzzy: the query letters appear in order, tighter matches score higher
+	span, ok := subsequenceSpan(field, query)
+	if !ok {
+		return 0
+	}
+
+	return max(1, 50-(span-len([]rune(query))))
+}
+
+// wordPrefix tells whether a word of field after the first starts with query.
+func wordPrefix(field, query string) bool {
+	words := strings.FieldsFunc(field, func(r rune) bool {
+		return r == ' ' || r == '/' || r == '-' || r == '_'
+	})
+
+	for _, word := range words[min(1, len(words)):] {
+		if strings.HasPrefix(word, query) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// subsequenceSpan finds the runes of query in field in order and returns how
+// many runes of field the match spans.
+func subsequenceSpan(field, query string) (int, bool) {
+	fieldRunes, queryRunes := []rune(field), []rune(query)
+
+	start, matched := -1, 0
+	for i, r := range fieldRunes {
+		if r != queryRunes[matched] {
+			continue
+		}
+
+		if start < 0 {
+			start = i
+		}
+
+		matched++
+		if matched == len(queryRunes) {
+			return i - start + 1, true
+		}
+	}
+
+	return 0, false
+}
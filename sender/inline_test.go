@@ -0,0 +1,93 @@
+package sender
+
+import (
+	"fmt"
+	"testing"
+)
+
+func testStickers() []InlineSticker {
+	return []InlineSticker{
+		{Name: "Anon", Symbol: "ANON", Address: "EQAjeq_aW_fSP7XqoF15ZZ7zUYiWLqv6UccN-jJlliomy-B3", Aliases: []string{"anonymous"}},
+		{Name: "Anon 7d", Symbol: "ANON", Address: "EQAjeq_aW_fSP7XqoF15ZZ7zUYiWLqv6UccN-jJlliomy-B3"},
+		{Name: "Gram", Symbol: "GRAM", Address: "EQASBZLwa2vfdsgoDF2w96pdccBJJRxDNXXPUL7NMm0WdnMx", Aliases: []string{"грам"}},
+		{Name: "Notcoin", Symbol: "NOT", Address: "EQAvlWFDxGF2lXm67y4yzC17wYKD9A0guwPkMs1gOsM__NOT"},
+	}
+}
+
+func names(stickers []InlineSticker) []string {
+	result := make([]string, len(stickers))
+	for i, sticker := range stickers {
+		result[i] = sticker.Name
+	}
+
+	return result
+}
+
+func TestRankStickers(t *testing.T) {
+	tests := []struct {
+		query    string
+		pinned   []string
+		expected string
+	}{
+		{"", nil, "[Anon Anon 7d Gram Notcoin]"},
+		{"", []string{"notcoin", "Gram"}, "[Notcoin Gram Anon Anon 7d]"},
+		{"anon", nil, "[Anon Anon 7d]"},
+		{"ANON", []string{"Anon 7d"}, "[Anon 7d Anon]"}, // both match the symbol exactly, pinned first
+		{"anon 7", []string{"Anon 7d"}, "[Anon 7d]"},
+		{"7d", nil, "[Anon 7d]"},
+		{"grm", nil, "[Gram]"},    // fuzzy
+		{"грам", nil, "[Gram]"},   // alias
+		{"EQASBZ", nil, "[Gram]"}, // address
+		{"not", nil, "[Notcoin]"}, // symbol before the address of Notcoin
+		{"xyz", nil, "[]"},
+	}
+
+	for _, test := range tests {
+		page, nextOffset := rankStickers(testStickers(), test.query, test.pinned, 0)
+
+		if result := fmt.Sprint(names(page)); result != test.expected {
+			t.Errorf("%q: expected %s, but got %s", test.query, test.expected, result)
+		}
+
+		if nextOffset != "" {
+			t.Errorf("%q: expected a single page, but got next offset %q", test.query, nextOffset)
+		}
+	}
+}
+
+func TestRankStickersPages(t *testing.T) {
+	stickers := make([]InlineSticker, 120)
+	for i := range stickers {
+		stickers[i] = InlineSticker{Name: fmt.Sprintf("Token %03d", i)}
+	}
+
+	var shown []string
+
+	offset := 0
+	for pages := 0; ; pages++ {
+		page, nextOffset := rankStickers(stickers, "token", nil, offset)
+		if len(page) > inlinePageSize {
+			t.Fatalf("Expected at most %d results, but got %d", inlinePageSize, len(page))
+		}
+
+		shown = append(shown, names(page)...)
+
+		if nextOffset == "" {
+			break
+		}
+
+		if pages > 3 {
+			t.Fatalf("Expected 3 pages, but got more")
+		}
+
+		fmt.Sscan(nextOffset, &offset)
+	}
+
+	if len(shown) != len(stickers) || shown[0] != "Token 000" || shown[len(shown)-1] != "Token 119" {
+		t.Errorf("Expected all 120 stickers in order, but got %d from %s to %s", len(shown), shown[0], shown[len(shown)-1])
+	}
+
+	if page, nextOffset := rankStickers(stickers, "", nil, 500); len(page) != 0 || nextOffset != "" {
+		t.Errorf("Expected nothing past the end, but got %d results and offset %q", len(page), nextOffset)
+	}
+}
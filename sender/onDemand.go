@@ -0,0 +1,155 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// failedRenderTTL caps how long a failed render is remembered, the pool
+	// may just be missing from the providers yet.
+	failedRenderTTL = time.Minute
+
+	// onDemandWait is how long an inline query waits for a render, Telegram
+	// drops answers to older queries.
+	onDemandWait = 3 * time.Second
+)
+
+// ErrRenderPending is returned when the render didn't finish in time, it
+// goes on and the sticker is cached for the next query.
+var ErrRenderPending = errors.New("the sticker is still rendering")
+
+// AddressRenderer renders and uploads the sticker of a pool or jetton address
+// that isn't in TOKENS_PATH.
+type AddressRenderer interface {
+	RenderAddress(ctx context.Context, address string) (InlineSticker, error)
+}
+
+// RateLimitError is returned when a user asks for more stickers than
+// ON_DEMAND_RATE_LIMIT allows.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited, retry in %s", e.RetryAfter)
+}
+
+// onDemandStickers caches rendered addresses and limits how many renders each
+// user may start. Cached stickers, including ones rendering for another user,
+// don't count against the limit.
+type onDemandStickers struct {
+	sync.Mutex
+	ttl    time.Duration
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	stickers map[string]*onDemandSticker
+	renders  map[int64][]time.Time // render start times of a user within the window
+}
+
+type onDemandSticker struct {
+	ready   chan struct{} // closed when the render finishes
+	sticker InlineSticker
+	err     error
+	expires time.Time
+}
+
+func newOnDemandStickers(ttl time.Duration, limit int, window time.Duration) *onDemandStickers {
+	return &onDemandStickers{
+		ttl:      ttl,
+		limit:    limit,
+		window:   window,
+		now:      time.Now,
+		stickers: make(map[string]*onDemandSticker),
+		renders:  make(map[int64][]time.Time),
+	}
+}
+
+// get returns the cached sticker of address or renders it for userID,
+// waiting for the render until ctx is done.
+func (o *onDemandStickers) get(ctx context.Context, userID int64, address string, render func(ctx context.Context, address string) (InlineSticker, error)) (InlineSticker, error) {
+	o.Lock()
+
+	now := o.now()
+	o.expire(now)
+
+	entry, ok := o.stickers[address]
+	if !ok {
+		if retryAfter := o.allow(userID, now); retryAfter > 0 {
+			o.Unlock()
+			return InlineSticker{}, &RateLimitError{RetryAfter: retryAfter}
+		}
+
+		entry = &onDemandSticker{ready: make(chan struct{})}
+		o.stickers[address] = entry
+	}
+
+	o.Unlock()
+
+	if !ok {
+		// the render outlives the query of the user who started it, others
+		// may be waiting for it
+		go func() {
+			sticker, err := render(context.WithoutCancel(ctx), address)
+
+			ttl := o.ttl
+			if err != nil {
+				ttl = min(ttl, failedRenderTTL)
+			}
+
+			o.Lock()
+			entry.sticker, entry.err, entry.expires = sticker, err, o.now().Add(ttl)
+			o.Unlock()
+
+			close(entry.ready)
+		}()
+	}
+
+	select {
+	case <-entry.ready:
+	case <-ctx.Done():
+		return InlineSticker{}, fmt.Errorf("%w: %w", ErrRenderPending, ctx.Err())
+	}
+
+	return entry.sticker, entry.err
+}
+
+// allow records a render of userID and returns 0, or how long the user has to
+// wait when the limit is reached.
+func (o *onDemandStickers) allow(userID int64, now time.Time) time.Duration {
+	renders := o.renders[userID]
+	if len(renders) >= o.limit {
+		return renders[0].Add(o.window).Sub(now)
+	}
+
+	o.renders[userID] = append(renders, now)
+
+	return 0
+}
+
+// expire drops finished renders past their ttl and renders out of the window.
+func (o *onDemandStickers) expire(now time.Time) {
+	for address, entry := range o.stickers {
+		if !entry.expires.IsZero() && !now.Before(entry.expires) {
+			delete(o.stickers, address)
+		}
+	}
+
+	for userID, renders := range o.renders {
+		i := 0
+		for i < len(renders) && !now.Before(renders[i].Add(o.window)) {
+			i++
+		}
+
+		if i == len(renders) {
+			delete(o.renders, userID)
+		} else {
+			o.renders[userID] = renders[i:]
+		}
+	}
+}
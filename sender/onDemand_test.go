@@ -0,0 +1,205 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testOnDemand(limit int) (*onDemandStickers, *time.Time) {
+	now := time.Date(2024, time.April, 6, 10, 30, 0, 0, time.UTC)
+
+	o := newOnDemandStickers(5*time.Minute, limit, 10*time.Minute)
+	o.now = func() time.Time { return now }
+
+	return o, &now
+}
+
+func countingRender(renders *atomic.Int32) func(context.Context, string) (InlineSticker, error) {
+	return func(ctx context.Context, address string) (InlineSticker, error) {
+		renders.Add(1)
+		return InlineSticker{Name: address, FileID: "file-" + address}, nil
+	}
+}
+
+func TestOnDemandCache(t *testing.T) {
+	o, now := testOnDemand(5)
+
+	var renders atomic.Int32
+
+	for _, userID := range []int64{1, 2, 1} {
+		sticker, err := o.get(context.Background(), userID, "EQa", countingRender(&renders))
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		if sticker.FileID != "file-EQa" {
+			t.Errorf("Expected file-EQa, but got %s", sticker.FileID)
+		}
+	}
+
+	if renders.Load() != 1 {
+		t.Errorf("Expected 1 render, but got %d", renders.Load())
+	}
+
+	*now = now.Add(5 * time.Minute)
+
+	if _, err := o.get(context.Background(), 2, "EQa", countingRender(&renders)); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if renders.Load() != 2 {
+		t.Errorf("Expected the expired sticker to be rendered again, but got %d renders", renders.Load())
+	}
+}
+
+func TestOnDemandRateLimit(t *testing.T) {
+	o, now := testOnDemand(2)
+
+	var renders atomic.Int32
+
+	render := func(userID int64, address string) error {
+		_, err := o.get(context.Background(), userID, address, countingRender(&renders))
+		return err
+	}
+
+	for _, address := range []string{"EQa", "EQb"} {
+		if err := render(1, address); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+	}
+
+	var rateLimitError *RateLimitError
+	if err := render(1, "EQc"); !errors.As(err, &rateLimitError) || rateLimitError.RetryAfter != 10*time.Minute {
+		t.Errorf("Expected to retry in 10m, but got %v", err)
+	}
+
+	// cached stickers and other users aren't limited
+	if err := render(1, "EQa"); err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+
+	if err := render(2, "EQc"); err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+
+	*now = now.Add(10 * time.Minute)
+
+	if err := render(1, "EQd"); err != nil {
+		t.Errorf("Expected no error after the window, but got %v", err)
+	}
+
+	if renders.Load() != 4 {
+		t.Errorf("Expected 4 renders, but got %d", renders.Load())
+	}
+}
+
+func TestOnDemandFailure(t *testing.T) {
+	o, now := testOnDemand(5)
+
+	renders := 0
+	failing := func(ctx context.Context, address string) (InlineSticker, error) {
+		renders++
+		return InlineSticker{}, errors.New("pool not found")
+	}
+
+	for range 2 {
+		if _, err := o.get(context.Background(), 1, "EQa", failing); err == nil {
+			t.Errorf("Expected an error")
+		}
+	}
+
+	*now = now.Add(failedRenderTTL)
+
+	if _, err := o.get(context.Background(), 1, "EQa", failing); err == nil {
+		t.Errorf("Expected an error")
+	}
+
+	if renders != 2 {
+		t.Errorf("Expected failures to be cached for %s, but got %d renders", failedRenderTTL, renders)
+	}
+}
+
+func TestOnDemandConcurrent(t *testing.T) {
+	o, _ := testOnDemand(1)
+
+	var renders atomic.Int32
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	slow := func(ctx context.Context, address string) (InlineSticker, error) {
+		renders.Add(1)
+		close(started)
+		<-release
+
+		return InlineSticker{FileID: "file"}, nil
+	}
+
+	var wg sync.WaitGroup
+
+	results := make([]InlineSticker, 3)
+	for i := range results {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if i > 0 {
+				<-started
+			}
+
+			results[i], _ = o.get(context.Background(), int64(i), "EQa", slow)
+		}()
+	}
+
+	<-started
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for i, sticker := range results {
+		if sticker.FileID != "file" {
+			t.Errorf("%d: expected the shared render, but got %+v", i, sticker)
+		}
+	}
+
+	if renders.Load() != 1 {
+		t.Errorf("Expected 1 render, but got %d", renders.Load())
+	}
+}
+
+func TestOnDemandPending(t *testing.T) {
+	o, _ := testOnDemand(5)
+
+	release := make(chan struct{})
+	slow := func(ctx context.Context, address string) (InlineSticker, error) {
+		<-release
+		return InlineSticker{FileID: "file"}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := o.get(ctx, 1, "EQa", slow); !errors.Is(err, ErrRenderPending) {
+		t.Fatalf("Expected a pending render, but got %v", err)
+	}
+
+	if button := onDemandErrorButton(ErrRenderPending); button.Text != "Drawing the sticker, type again in a moment" {
+		t.Errorf("Expected the pending button, but got %q", button.Text)
+	}
+
+	// the render went on and the next query gets it without a new one
+	close(release)
+
+	sticker, err := o.get(context.Background(), 1, "EQa", func(ctx context.Context, address string) (InlineSticker, error) {
+		t.Errorf("Expected no second render")
+		return InlineSticker{}, nil
+	})
+	if err != nil || sticker.FileID != "file" {
+		t.Errorf("Expected the finished render, but got %+v and %v", sticker, err)
+	}
+}
@@ -5,10 +5,10 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/ad/anonstickerbot/config"
 	"github.com/go-telegram/bot"
-	"github.com/go-telegram/bot/models"
 	bm "github.com/go-telegram/bot/models"
 )
 
@@ -18,16 +18,30 @@ type Sender struct {
 	config           *config.Config
 	Bot              *bot.Bot
 	Config           *config.Config
-	LastStickers     map[string]string
+	LastStickers     map[string]InlineSticker
+	Renderer         AddressRenderer // renders addresses missing from LastStickers, off when nil
+	Username         string          // of the bot, commands to other bots are ignored
+	commands         map[string]CommandHandler
+	onDemand         *onDemandStickers
 	deferredMessages map[int64]chan DeferredMessage
 	lastMessageTimes map[int64]int64
+	queueCancel      context.CancelFunc
+	queueDone        chan struct{}
 }
 
+// InitSender creates the bot. Polling is started by running sender.Bot.Start
+// and the deferred messages queue by the Start method of the sender.
 func InitSender(ctx context.Context, logger *slog.Logger, config *config.Config) (*Sender, error) {
 	sender := &Sender{
-		logger:           logger,
-		config:           config,
-		LastStickers:     make(map[string]string),
+		logger:       logger,
+		config:       config,
+		LastStickers: make(map[string]InlineSticker),
+		commands:     make(map[string]CommandHandler),
+		onDemand: newOnDemandStickers(
+			time.Duration(config.ON_DEMAND_CACHE_TTL)*time.Second,
+			config.ON_DEMAND_RATE_LIMIT,
+			time.Duration(config.ON_DEMAND_RATE_WINDOW)*time.Second,
+		),
 		deferredMessages: make(map[int64]chan DeferredMessage),
 		lastMessageTimes: make(map[int64]int64),
 	}
@@ -42,39 +56,50 @@ func InitSender(ctx context.Context, logger *slog.Logger, config *config.Config)
 		return nil, fmt.Errorf("start bot error: %s", newBotError)
 	}
 
-	go b.Start(ctx)
-	go sender.sendDeferredMessages()
-
 	sender.Bot = b
 
 	return sender, nil
 }
 
-func (s *Sender) handler(ctx context.Context, b *bot.Bot, update *bm.Update) {
-	if s.config.Debug {
-		s.logger.Debug(formatUpdateForLog(update))
-	}
+func (s *Sender) Name() string {
+	return "sender queue"
+}
 
-	if update.InlineQuery == nil {
-		return
-	}
+// Start runs the deferred messages queue.
+func (s *Sender) Start(ctx context.Context) error {
+	ctx, s.queueCancel = context.WithCancel(ctx)
+	s.queueDone = make(chan struct{})
 
-	s.RLock()
-	defer s.RUnlock()
+	go func() {
+		defer close(s.queueDone)
+		s.sendDeferredMessages(ctx)
+	}()
 
-	results := []models.InlineQueryResult{}
+	return nil
+}
 
-	for name, fileID := range s.LastStickers {
-		results = append(results, &models.InlineQueryResultCachedSticker{ID: name, StickerFileID: fileID})
+// Stop stops the deferred messages queue and sends whatever is left in it.
+func (s *Sender) Stop(ctx context.Context) error {
+	s.queueCancel()
+
+	select {
+	case <-s.queueDone:
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 
-	_, err := b.AnswerInlineQuery(ctx, &bot.AnswerInlineQueryParams{
-		InlineQueryID: update.InlineQuery.ID,
-		Results:       results,
-		CacheTime:     0,
-	})
+	return s.flushDeferredMessages(ctx)
+}
+
+func (s *Sender) handler(ctx context.Context, b *bot.Bot, update *bm.Update) {
+	if s.config.Debug {
+		s.logger.Debug(formatUpdateForLog(update))
+	}
 
-	if err != nil {
-		fmt.Printf("answer inline query error %s\n", err.Error())
+	switch {
+	case update.InlineQuery != nil:
+		s.answerInlineQuery(ctx, b, update.InlineQuery)
+	case update.Message != nil:
+		s.handleCommand(ctx, b, update.Message)
 	}
 }
@@ -0,0 +1,399 @@
+package stickerUpdater
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"image"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ad/anonstickerbot/config"
+	"github.com/ad/anonstickerbot/sender"
+	"github.com/dustin/go-humanize"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	xdraw "golang.org/x/image/draw"
+)
+
+// maxTemplateSize limits the downloaded template of /addtoken.
+const maxTemplateSize = 10 << 20
+
+// tokenInfo is the info.json written by /addtoken.
+type tokenInfo struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Emoji   string `json:"emoji"`
+}
+
+// registerAdminCommands adds the commands managing tokens at runtime. Added
+// and removed tokens are saved to TOKENS_PATH, pauses last until restart.
+func (su *StickerUpdater) registerAdminCommands() {
+	su.sender.RegisterAdminCommand("addtoken", su.addTokenCommand)
+	su.sender.RegisterAdminCommand("removetoken", su.removeTokenCommand)
+	su.sender.RegisterAdminCommand("listtokens", su.listTokensCommand)
+	su.sender.RegisterAdminCommand("refresh", su.refreshCommand)
+	su.sender.RegisterAdminCommand("pause", su.pauseCommand(true))
+	su.sender.RegisterAdminCommand("resume", su.pauseCommand(false))
+}
+
+func (su *StickerUpdater) addTokenCommand(ctx context.Context, b *bot.Bot, message *models.Message, args []string) {
+	if len(args) < 3 {
+		su.reply(ctx, b, message, html.EscapeString("Usage: /addtoken <name> <address> <emoji>, with a 512x512 webp or png template attached or without one to generate it"))
+		return
+	}
+
+	// the name may have spaces
+	name := strings.Join(args[:len(args)-2], " ")
+	emoji := args[len(args)-1]
+
+	address, ok := sender.ParseTONAddress(args[len(args)-2])
+	if !ok {
+		su.reply(ctx, b, message, fmt.Sprintf("%s is not a TON address", html.EscapeString(args[len(args)-2])))
+		return
+	}
+
+	var template image.Image
+
+	if fileID := attachedImage(message); fileID != "" {
+		data, err := su.downloadFile(ctx, b, fileID)
+		if err != nil {
+			su.logger.Error(fmt.Sprintf("/addtoken %s template error: %s", name, err))
+			su.reply(ctx, b, message, "Can't download the template, try again")
+
+			return
+		}
+
+		if template, _, err = image.Decode(bytes.NewReader(data)); err != nil {
+			su.reply(ctx, b, message, html.EscapeString(fmt.Sprintf("Can't read the template: %s", err)))
+			return
+		}
+	}
+
+	stickerConfig, err := su.addToken(name, address, emoji, template)
+	if err != nil {
+		su.reply(ctx, b, message, html.EscapeString(err.Error()))
+		return
+	}
+
+	su.reply(ctx, b, message, html.EscapeString(fmt.Sprintf("Added %s in %s, the first sticker is coming", stickerConfig.Name, stickerConfig.dir)))
+}
+
+func (su *StickerUpdater) removeTokenCommand(ctx context.Context, b *bot.Bot, message *models.Message, args []string) {
+	if len(args) == 0 {
+		su.reply(ctx, b, message, html.EscapeString("Usage: /removetoken <token>"))
+		return
+	}
+
+	names, moved, err := su.removeToken(strings.Join(args, " "))
+	if err != nil {
+		su.reply(ctx, b, message, html.EscapeString(err.Error()))
+		return
+	}
+
+	su.reply(ctx, b, message, html.EscapeString(fmt.Sprintf("Removed %s, the files are in %s", strings.Join(names, ", "), moved)))
+}
+
+func (su *StickerUpdater) listTokensCommand(ctx context.Context, b *bot.Bot, message *models.Message, args []string) {
+	su.reply(ctx, b, message, su.tokenList(time.Now()))
+}
+
+func (su *StickerUpdater) refreshCommand(ctx context.Context, b *bot.Bot, message *models.Message, args []string) {
+	if len(args) == 0 {
+		su.reply(ctx, b, message, html.EscapeString("Usage: /refresh <token>"))
+		return
+	}
+
+	stickerConfig, err := su.findSticker(strings.Join(args, " "))
+	if err != nil {
+		su.reply(ctx, b, message, html.EscapeString(err.Error()))
+		return
+	}
+
+	if err := su.Run(ctx, stickerConfig.Name); err != nil {
+		su.reply(ctx, b, message, html.EscapeString(err.Error()))
+		return
+	}
+
+	su.reply(ctx, b, message, html.EscapeString(fmt.Sprintf("%s updated", stickerConfig.Name)))
+}
+
+// pauseCommand handles /pause and /resume of every token or of one.
+func (su *StickerUpdater) pauseCommand(paused bool) sender.CommandHandler {
+	action := "resumed"
+	if paused {
+		action = "paused"
+	}
+
+	return func(ctx context.Context, b *bot.Bot, message *models.Message, args []string) {
+		if len(args) == 0 {
+			su.setPaused("", paused)
+			su.reply(ctx, b, message, fmt.Sprintf("Updates %s", action))
+
+			return
+		}
+
+		stickerConfig, err := su.findSticker(strings.Join(args, " "))
+		if err != nil {
+			su.reply(ctx, b, message, html.EscapeString(err.Error()))
+			return
+		}
+
+		su.setPaused(stickerConfig.Name, paused)
+		su.reply(ctx, b, message, html.EscapeString(fmt.Sprintf("%s %s", stickerConfig.Name, action)))
+	}
+}
+
+// attachedImage returns the file of the document or photo of the message.
+func attachedImage(message *models.Message) string {
+	if message.Document != nil && strings.HasPrefix(message.Document.MimeType, "image/") {
+		return message.Document.FileID
+	}
+
+	// the largest size is the last
+	if len(message.Photo) > 0 {
+		return message.Photo[len(message.Photo)-1].FileID
+	}
+
+	return ""
+}
+
+func (su *StickerUpdater) downloadFile(ctx context.Context, b *bot.Bot, fileID string) ([]byte, error) {
+	ctx, cancel := config.WithTimeout(ctx, su.config.HTTP_TIMEOUT)
+	defer cancel()
+
+	file, err := b.GetFile(ctx, &bot.GetFileParams{FileID: fileID})
+	if err != nil {
+		return nil, err
+	}
+
+	if file.FileSize > maxTemplateSize {
+		return nil, fmt.Errorf("%s is %d bytes, at most %d", file.FilePath, file.FileSize, maxTemplateSize)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", b.FileDownloadLink(file), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("%s: status code: %d", file.FilePath, resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxTemplateSize))
+}
+
+// addToken saves a new token to TOKENS_PATH and starts updating it. Without
+// template the sticker template is generated.
+func (su *StickerUpdater) addToken(name, address, emoji string, template image.Image) (*StickerConfig, error) {
+	dirName := tokenDirName(name)
+	if dirName == "" {
+		return nil, fmt.Errorf("name %q has no letters or digits", name)
+	}
+
+	su.reloadMutex.Lock()
+	defer su.reloadMutex.Unlock()
+
+	if stickerConfig, err := su.findSticker(name); err == nil {
+		return nil, fmt.Errorf("%s already exists in %s", stickerConfig.Name, stickerConfig.dir)
+	}
+
+	dir := fmt.Sprintf("%s/%s", su.config.TOKENS_PATH, dirName)
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s already exists", dir)
+	}
+
+	info, err := json.MarshalIndent(tokenInfo{Name: name, Address: address, Emoji: emoji}, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+
+	var webpTemplate []byte
+	if template != nil {
+		if template, err = stickerTemplate(template); err != nil {
+			return nil, err
+		}
+
+		// lossless, the template is encoded again with every sticker
+		if webpTemplate, err = encodeLossless(template, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	// written next to the tokens under a skipped name and renamed when
+	// complete, so a half-written token is never loaded
+	tmp, err := os.MkdirTemp(su.config.TOKENS_PATH, ".new-")
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.RemoveAll(tmp)
+
+	if err := os.WriteFile(tmp+"/info.json", info, 0o644); err != nil {
+		return nil, err
+	}
+
+	if webpTemplate != nil {
+		if err := os.WriteFile(tmp+"/sticker.webp", webpTemplate, 0o644); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.Chmod(tmp, 0o755); err != nil {
+		return nil, err
+	}
+
+	if err := os.Rename(tmp, dir); err != nil {
+		return nil, err
+	}
+
+	// loaded here, not again by the next reload
+	su.tokenVersions[dir] = tokenVersion(dir)
+
+	stickerConfigs, err := su.loadTokenDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return stickerConfigs[0], nil
+}
+
+// removeToken stops updating the stickers of the token directory and moves
+// it to a name starting with "_", skipped by the loader, so it can be
+// restored. It returns the removed sticker names and the new directory.
+func (su *StickerUpdater) removeToken(token string) ([]string, string, error) {
+	// the directory can't be reloaded or removed once found
+	su.reloadMutex.Lock()
+	defer su.reloadMutex.Unlock()
+
+	stickerConfig, err := su.findSticker(token)
+	if err != nil {
+		return nil, "", err
+	}
+
+	dir := stickerConfig.dir
+
+	moved := fmt.Sprintf("%s/_%s", filepath.Dir(dir), filepath.Base(dir))
+	if _, err := os.Stat(moved); !os.IsNotExist(err) {
+		moved += "-" + time.Now().Format("20060102150405")
+	}
+
+	if err := os.Rename(dir, moved); err != nil {
+		return nil, "", err
+	}
+
+	delete(su.tokenVersions, dir)
+
+	return su.unloadToken(dir), moved, nil
+}
+
+// setPaused pauses or resumes the sticker name, every sticker when empty.
+func (su *StickerUpdater) setPaused(name string, paused bool) {
+	su.stateMutex.Lock()
+	defer su.stateMutex.Unlock()
+
+	if name == "" {
+		su.pausedAll = paused
+		clear(su.paused)
+
+		return
+	}
+
+	if paused {
+		su.paused[name] = true
+	} else {
+		delete(su.paused, name)
+	}
+}
+
+// tokenList is the /listtokens text: every sticker with its address and the
+// state of its updates.
+func (su *StickerUpdater) tokenList(now time.Time) string {
+	su.stateMutex.Lock()
+	defer su.stateMutex.Unlock()
+
+	names := make([]string, 0, len(su.stickers))
+	for name := range su.stickers {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	title := fmt.Sprintf("<b>Tokens: %d</b>", len(names))
+	if su.pausedAll {
+		title += ", updates paused"
+	}
+
+	lines := []string{title}
+
+	for _, name := range names {
+		stickerConfig := su.stickers[name]
+
+		status := "waiting"
+		state := su.states[name]
+
+		switch {
+		case su.paused[name]:
+			status = "paused"
+		case state != nil && state.failures > 0:
+			status = fmt.Sprintf("failing %d times: %s", state.failures, state.lastError)
+		case state != nil && !state.lastSuccess.IsZero():
+			status = "updated " + humanize.RelTime(state.lastSuccess, now, "ago", "from now")
+		}
+
+		lines = append(lines, html.EscapeString(fmt.Sprintf(
+			"%s %s %s, %s, every %s, %s",
+			name, stickerConfig.Emoji, stickerConfig.Address, filepath.Base(stickerConfig.dir), su.interval(stickerConfig), status,
+		)))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// tokenDirName is the directory of a new token: its name in lowercase with
+// dashes instead of anything but letters and digits.
+func tokenDirName(name string) string {
+	dashed := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+
+		return '-'
+	}, name)
+
+	parts := strings.FieldsFunc(dashed, func(r rune) bool { return r == '-' })
+
+	return strings.Join(parts, "-")
+}
+
+// stickerTemplate scales a square template to the sticker size.
+func stickerTemplate(template image.Image) (image.Image, error) {
+	bounds := template.Bounds()
+	if bounds.Dx() != bounds.Dy() {
+		return nil, fmt.Errorf("the template is %dx%d, it must be square, %dx%d", bounds.Dx(), bounds.Dy(), stickerSize, stickerSize)
+	}
+
+	if bounds.Dx() == stickerSize {
+		return template, nil
+	}
+
+	scaled := image.NewNRGBA(image.Rect(0, 0, stickerSize, stickerSize))
+	xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), template, bounds, xdraw.Src, nil)
+
+	return scaled, nil
+}
@@ -0,0 +1,193 @@
+package stickerUpdater
+
+import (
+	"image"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ad/anonstickerbot/config"
+)
+
+func testAdminUpdater(t *testing.T) *StickerUpdater {
+	t.Helper()
+
+	su, err := InitStickerUpdater(slog.New(slog.DiscardHandler), &config.Config{
+		TOKENS_PATH:  t.TempDir(),
+		THEME:        "dark",
+		LOCALE:       "en",
+		TIMEZONE:     "UTC",
+		UPDATE_DELAY: 60,
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	return su
+}
+
+func TestAddToken(t *testing.T) {
+	su := testAdminUpdater(t)
+
+	stickerConfig, err := su.addToken("Anon Token", "pool", "🐸", image.NewNRGBA(image.Rect(0, 0, 256, 256)))
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if stickerConfig.dir != su.config.TOKENS_PATH+"/anon-token" {
+		t.Errorf("Expected anon-token directory, but got %s", stickerConfig.dir)
+	}
+
+	if bounds := stickerConfig.image.Bounds(); bounds.Dx() != stickerSize || bounds.Dy() != stickerSize {
+		t.Errorf("Expected the template scaled to %d, but got %v", stickerSize, bounds)
+	}
+
+	if su.stickers["Anon Token"] != stickerConfig {
+		t.Errorf("Expected Anon Token to be updated")
+	}
+
+	// loaded the same way after restart
+	restarted, err := InitStickerUpdater(slog.New(slog.DiscardHandler), su.config, nil, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if loaded := restarted.stickers["Anon Token"]; loaded == nil || loaded.Address != "pool" || loaded.Emoji != "🐸" {
+		t.Errorf("Expected Anon Token of pool after restart, but got %+v", loaded)
+	}
+
+	if _, err := su.addToken("anon token", "pool", "🐸", nil); err == nil {
+		t.Errorf("Expected an error for a duplicate name")
+	}
+
+	if _, err := su.addToken("Wide", "pool", "🐸", image.NewNRGBA(image.Rect(0, 0, 512, 256))); err == nil {
+		t.Errorf("Expected an error for a non-square template")
+	}
+
+	if _, err := os.Stat(su.config.TOKENS_PATH + "/wide"); !os.IsNotExist(err) {
+		t.Errorf("Expected no directory of a rejected token, but got %v", err)
+	}
+
+	// the template is generated without one
+	generated, err := su.addToken("Plain", "pool2", "🐸", nil)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if generated.image == nil {
+		t.Errorf("Expected a generated template")
+	}
+
+	entries, _ := os.ReadDir(su.config.TOKENS_PATH)
+	if len(entries) != 2 {
+		t.Errorf("Expected 2 token directories and no temporary ones, but got %d", len(entries))
+	}
+}
+
+func TestRemoveToken(t *testing.T) {
+	su := testAdminUpdater(t)
+
+	dir := su.config.TOKENS_PATH + "/anon"
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	info := `{"name": "Anon", "address": "pool", "stickers": [{"name": "Anon 1d", "timeframe": "1h"}]}`
+	if err := os.WriteFile(dir+"/info.json", []byte(info), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stickerConfigs, err := su.loadToken(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	for _, stickerConfig := range stickerConfigs {
+		su.stickers[stickerConfig.Name] = stickerConfig
+	}
+
+	su.setPaused("Anon 1d", true)
+
+	names, moved, err := su.removeToken("anon")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if strings.Join(names, ",") != "Anon,Anon 1d" {
+		t.Errorf("Expected Anon and its variant removed, but got %v", names)
+	}
+
+	if moved != su.config.TOKENS_PATH+"/_anon" {
+		t.Errorf("Expected the directory moved to _anon, but got %s", moved)
+	}
+
+	if _, err := os.Stat(moved + "/info.json"); err != nil {
+		t.Errorf("Expected info.json kept, but got %v", err)
+	}
+
+	if len(su.stickers) != 0 || len(su.paused) != 0 {
+		t.Errorf("Expected no stickers left, but got %d and %d paused", len(su.stickers), len(su.paused))
+	}
+
+	if _, _, err := su.removeToken("anon"); err == nil {
+		t.Errorf("Expected an error for a removed token")
+	}
+}
+
+func TestSetPaused(t *testing.T) {
+	su := &StickerUpdater{paused: make(map[string]bool)}
+
+	su.setPaused("Anon", true)
+	if !su.paused["Anon"] {
+		t.Errorf("Expected Anon paused")
+	}
+
+	su.setPaused("", true)
+	if !su.pausedAll || len(su.paused) != 0 {
+		t.Errorf("Expected every sticker paused, but got %v and %v", su.pausedAll, su.paused)
+	}
+
+	su.setPaused("", false)
+	if su.pausedAll {
+		t.Errorf("Expected updates resumed")
+	}
+}
+
+func TestTokenList(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	su := &StickerUpdater{
+		config: &config.Config{UPDATE_DELAY: 60},
+		stickers: map[string]*StickerConfig{
+			"Anon":  {Name: "Anon", Address: "pool", Emoji: "🐸", dir: "tokens/anon"},
+			"Other": {Name: "Other", Address: "pool2", Emoji: "💎", dir: "tokens/other"},
+		},
+		states: map[string]*tokenState{
+			"Anon": {lastSuccess: now.Add(-2 * time.Minute)},
+		},
+		paused: map[string]bool{"Other": true},
+	}
+
+	expected := "<b>Tokens: 2</b>\n" +
+		"Anon 🐸 pool, anon, every 1m0s, updated 2 minutes ago\n" +
+		"Other 💎 pool2, other, every 1m0s, paused"
+
+	if list := su.tokenList(now); list != expected {
+		t.Errorf("Expected %q, but got %q", expected, list)
+	}
+}
+
+func TestTokenDirName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Anon":         "anon",
+		"Anon Token 2": "anon-token-2",
+		" $ANON / TON": "anon-ton",
+		"🐸":            "",
+	} {
+		if dirName := tokenDirName(name); dirName != expected {
+			t.Errorf("Expected %q for %q, but got %q", expected, name, dirName)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package stickerUpdater
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+)
+
+const (
+	AnimationDraw    = "draw"    // the chart is drawn candle by candle
+	AnimationCountUp = "countup" // the price counts up to the current one
+
+	animationFPS    = 20
+	animationFrames = 3 * animationFPS // video stickers can't be longer than 3 seconds
+	animationHold   = 0.25             // share of the video the final frame is shown
+)
+
+// animatedSticker renders and encodes a video sticker.
+func (su *StickerUpdater) animatedSticker(ctx context.Context, stickerConfig *StickerConfig, data PoolSnapshot, candles []Candle) ([]byte, error) {
+	frames, err := su.renderAnimation(stickerConfig, data, candles)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return encodeWebM(ctx, su.config.FFMPEG_PATH, frames, animationFPS)
+}
+
+// renderAnimation renders the frames of the animation set in stickerConfig.
+// Without candles or chart regions the draw animation falls back to count up.
+func (su *StickerUpdater) renderAnimation(stickerConfig *StickerConfig, data PoolSnapshot, candles []Candle) ([]image.Image, error) {
+	switch stickerConfig.Animation {
+	case AnimationDraw:
+		if len(candles) > 0 && len(stickerConfig.layout.Charts) > 0 {
+			return su.renderDrawFrames(stickerConfig, data, candles)
+		}
+
+		return su.renderCountUpFrames(stickerConfig, data, candles)
+	case AnimationCountUp:
+		return su.renderCountUpFrames(stickerConfig, data, candles)
+	}
+
+	return nil, fmt.Errorf("unknown animation %q", stickerConfig.Animation)
+}
+
+func (su *StickerUpdater) renderDrawFrames(stickerConfig *StickerConfig, data PoolSnapshot, candles []Candle) ([]image.Image, error) {
+	background, err := su.renderText(stickerConfig, data)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := make([]image.Image, 0, animationFrames)
+
+	for i := range animationFrames {
+		visible := max(1, int(math.Ceil(animationProgress(i)*float64(len(candles)))))
+
+		frame, err := su.drawCharts(background, stickerConfig, candles, visible)
+		if err != nil {
+			return nil, err
+		}
+
+		frames = append(frames, frame)
+	}
+
+	return frames, nil
+}
+
+// renderCountUpFrames counts prices up from the first candle close, or from
+// zero without candles, to the current price. The chart is drawn in full.
+func (su *StickerUpdater) renderCountUpFrames(stickerConfig *StickerConfig, data PoolSnapshot, candles []Candle) ([]image.Image, error) {
+	var from float64
+	if len(candles) > 0 {
+		from = candles[0].Close
+	}
+
+	frames := make([]image.Image, 0, animationFrames)
+
+	for i := range animationFrames {
+		frame, err := su.render(stickerConfig, countUp(data, from, easeOut(animationProgress(i))), candles)
+		if err != nil {
+			return nil, err
+		}
+
+		frames = append(frames, frame)
+	}
+
+	return frames, nil
+}
+
+// countUp returns data with the price and values derived from it at progress
+// between from and the current price.
+func countUp(data PoolSnapshot, from, progress float64) PoolSnapshot {
+	if data.PriceUSD == 0 {
+		return data
+	}
+
+	price := from + (data.PriceUSD-from)*progress
+	ratio := price / data.PriceUSD
+
+	data.PriceUSD = price
+	data.BaseTokenPriceQuoteToken *= ratio
+	data.FdvUSD *= ratio
+
+	return data
+}
+
+// animationProgress maps frame to 0..1, reaching 1 before the final hold.
+func animationProgress(frame int) float64 {
+	last := float64(animationFrames)*(1-animationHold) - 1
+
+	return math.Min(1, float64(frame)/last)
+}
+
+func easeOut(progress float64) float64 {
+	return 1 - math.Pow(1-progress, 3)
+}
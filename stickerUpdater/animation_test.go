@@ -0,0 +1,143 @@
+package stickerUpdater
+
+import (
+	"image"
+	"os"
+	"testing"
+	"time"
+)
+
+func testAnimationUpdater(t *testing.T) (*StickerUpdater, *StickerConfig) {
+	t.Helper()
+
+	layout, err := parseLayout(defaultLayoutJSON)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	su := &StickerUpdater{fonts: NewFontCache("")}
+	stickerConfig := &StickerConfig{
+		Name:   "test",
+		image:  image.NewNRGBA(image.Rect(0, 0, 512, 512)),
+		layout: layout,
+	}
+
+	return su, stickerConfig
+}
+
+func testCandles(count int) []Candle {
+	candles := make([]Candle, count)
+	start := time.Unix(0, 0)
+
+	for i := range candles {
+		price := float64(10 + i)
+		candles[i] = Candle{Time: start.Add(time.Duration(i) * 15 * time.Minute), Open: price, High: price + 1, Low: price - 1, Close: price + 0.5}
+	}
+
+	return candles
+}
+
+func TestRenderAnimationDraw(t *testing.T) {
+	su, stickerConfig := testAnimationUpdater(t)
+	stickerConfig.Animation = AnimationDraw
+
+	frames, err := su.renderAnimation(stickerConfig, PoolSnapshot{Name: "TEST / TON", PriceUSD: 1}, testCandles(24))
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if len(frames) != animationFrames {
+		t.Fatalf("Expected %d frames, but got %d", animationFrames, len(frames))
+	}
+
+	if frames[0].Bounds().Dx() != 512 || frames[0].Bounds().Dy() != 512 {
+		t.Errorf("Expected 512x512 frames, but got %v", frames[0].Bounds())
+	}
+
+	// the last candle is the rightmost one, it must appear only at the end
+	x := 512 - 5 - 1
+	for x > 0 && frames[len(frames)-1].(*image.NRGBA).NRGBAAt(x, 400).A == 0 {
+		x--
+	}
+	column := func(frame image.Image) bool {
+		for y := 300; y < 512; y++ {
+			if frame.(*image.NRGBA).NRGBAAt(x, y).A != 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	if column(frames[0]) {
+		t.Errorf("Expected the last candle to be missing in the first frame")
+	}
+	if !column(frames[len(frames)-1]) {
+		t.Errorf("Expected the last candle in the last frame")
+	}
+}
+
+func TestRenderAnimationUnknown(t *testing.T) {
+	su, stickerConfig := testAnimationUpdater(t)
+	stickerConfig.Animation = "spin"
+
+	if _, err := su.renderAnimation(stickerConfig, PoolSnapshot{}, nil); err == nil {
+		t.Errorf("Expected error for unknown animation, but got nil")
+	}
+}
+
+func TestCountUp(t *testing.T) {
+	data := PoolSnapshot{PriceUSD: 2, BaseTokenPriceQuoteToken: 4, FdvUSD: 200}
+
+	start := countUp(data, 1, 0)
+	if start.PriceUSD != 1 || start.BaseTokenPriceQuoteToken != 2 || start.FdvUSD != 100 {
+		t.Errorf("Expected prices at the start value, but got %+v", start)
+	}
+
+	end := countUp(data, 1, 1)
+	if end != data {
+		t.Errorf("Expected %+v, but got %+v", data, end)
+	}
+}
+
+func TestAnimationProgress(t *testing.T) {
+	if animationProgress(0) != 0 {
+		t.Errorf("Expected 0, but got %f", animationProgress(0))
+	}
+
+	if animationProgress(animationFrames-1) != 1 {
+		t.Errorf("Expected 1, but got %f", animationProgress(animationFrames-1))
+	}
+}
+
+func TestLoadTokenWithoutFFmpeg(t *testing.T) {
+	su := testAdminUpdater(t)
+	dir := su.config.TOKENS_PATH + "/anon"
+
+	writeToken(t, dir, `{"name": "Anon", "address": "pool", "animation": "draw"}`, time.Now())
+
+	su.config.FFMPEG_PATH = "no-such-ffmpeg"
+
+	stickerConfigs, err := su.loadToken(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if stickerConfigs[0].Animation != "" {
+		t.Errorf("Expected the animation off without ffmpeg, but got %q", stickerConfigs[0].Animation)
+	}
+
+	// any binary stands for ffmpeg
+	su.config.FFMPEG_PATH, err = os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stickerConfigs, err = su.loadToken(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if stickerConfigs[0].Animation != AnimationDraw {
+		t.Errorf("Expected the draw animation, but got %q", stickerConfigs[0].Animation)
+	}
+}
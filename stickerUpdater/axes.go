@@ -0,0 +1,252 @@
+package stickerUpdater
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	defaultLabelSize  = 12
+	defaultGridRows   = 4
+	defaultGridAlpha  = 0x26
+	defaultLabelAlpha = 0xb3
+
+	labelPadding    = 4
+	timeLabelCount  = 4
+	lastPriceDash   = 3
+	subscriptZeroes = 4 // prices with this many zeros after the point use 0.0₄123
+)
+
+// Axes are the optional gridlines, labels and last price marker of a chart.
+// Labels are not drawn without Face.
+type Axes struct {
+	Grid        bool
+	PriceLabels bool
+	TimeLabels  bool
+	LastPrice   bool
+	Face        font.Face
+	GridColor   color.NRGBA
+	LabelColor  color.NRGBA
+	Locale      *Locale        // the default locale when nil
+	Location    *time.Location // of time labels, UTC when nil
+}
+
+func (axes Axes) price(value float64) string {
+	if axes.Locale == nil {
+		return formatPrice(value)
+	}
+
+	return axes.Locale.price(value)
+}
+
+func (axes Axes) labelHeight() int {
+	if axes.Face == nil {
+		return 0
+	}
+
+	return axes.Face.Metrics().Height.Ceil()
+}
+
+func (axes Axes) textWidth(text string) int {
+	return font.MeasureString(axes.Face, text).Ceil()
+}
+
+// priceLabelWidth is the space taken on the right of the chart by price
+// labels and the last price tag.
+func (axes Axes) priceLabelWidth(scale chartScale, rows int, last float64) int {
+	if axes.Face == nil || !axes.PriceLabels && !axes.LastPrice {
+		return 0
+	}
+
+	width := axes.textWidth(axes.price(last))
+	if axes.PriceLabels {
+		for _, value := range gridValues(scale, rows) {
+			width = max(width, axes.textWidth(axes.price(value)))
+		}
+	}
+
+	return width + 2*labelPadding
+}
+
+// timeLabelHeight is the space taken under the chart by time labels.
+func (axes Axes) timeLabelHeight() int {
+	if axes.Face == nil || !axes.TimeLabels {
+		return 0
+	}
+
+	return axes.labelHeight() + labelPadding
+}
+
+// drawGrid draws rows+1 horizontal lines and labels as many of them as fit.
+func drawGrid(img *image.NRGBA, scale chartScale, opts Options, right int) {
+	axes := opts.Axes
+	values := gridValues(scale, opts.Rows)
+	rows := len(values) - 1
+
+	rowHeight := float64(scale.chartHmin-scale.chartHmax) / float64(rows)
+	labelEvery := 1
+	if axes.Face != nil && rowHeight > 0 {
+		labelEvery = max(1, int(math.Ceil(1.5*float64(axes.labelHeight())/rowHeight)))
+	}
+
+	for i, value := range values {
+		y := scale.y(value)
+
+		if axes.Grid {
+			for x := opts.XOffset; x < right; x++ {
+				blend(img, x, y, axes.GridColor)
+			}
+		}
+
+		if axes.PriceLabels && axes.Face != nil && i%labelEvery == 0 {
+			drawLabel(img, axes.Face, axes.price(value), right+labelPadding, y, axes.LabelColor)
+		}
+	}
+}
+
+// gridValues returns the prices of rows+1 gridlines covering the chart.
+func gridValues(scale chartScale, rows int) []float64 {
+	if rows <= 0 {
+		rows = defaultGridRows
+	}
+
+	values := make([]float64, rows+1)
+	for i := range values {
+		values[i] = scale.lowerValue + (scale.higherValue-scale.lowerValue)*float64(i)/float64(rows)
+	}
+
+	return values
+}
+
+// drawTimeLabels draws a few candle times evenly spread under the chart.
+func drawTimeLabels(img *image.NRGBA, data []Candle, scale chartScale, opts Options, bottom int) {
+	axes := opts.Axes
+	if axes.Face == nil {
+		return
+	}
+
+	layout := "15:04"
+	if scale.timeDiff >= 48*time.Hour {
+		layout = "02 Jan"
+	}
+
+	location := axes.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	count := min(timeLabelCount, len(data))
+	y := bottom + labelPadding + axes.Face.Metrics().Ascent.Ceil()
+
+	for i := range count {
+		index := 0
+		if count > 1 {
+			index = i * (len(data) - 1) / (count - 1)
+		}
+
+		text := data[index].Time.In(location).Format(layout)
+		width := axes.textWidth(text)
+		x := min(max(scale.x(data[index].Time)-width/2, opts.XOffset), opts.Width-width)
+
+		drawText(img, axes.Face, text, x, y, axes.LabelColor)
+	}
+}
+
+// drawLastPrice draws a dashed line at the last close and a tag with the
+// price on the right of the chart.
+func drawLastPrice(img *image.NRGBA, last Candle, scale chartScale, opts Options, right int) {
+	axes := opts.Axes
+	markerColor := opts.Theme.candleColor(last.Open, last.Close)
+	y := min(max(scale.y(last.Close), scale.chartHmax), scale.chartHmin)
+
+	for x := opts.XOffset; x < right; x += 2 * lastPriceDash {
+		line(x, min(x+lastPriceDash-1, right-1), y, y, markerColor, img)
+	}
+
+	if axes.Face == nil {
+		return
+	}
+
+	height := axes.labelHeight()
+	line(right, opts.Width-1, y-height/2-1, y+height/2, markerColor, img)
+	drawLabel(img, axes.Face, axes.price(last.Close), right+labelPadding, y, opts.Theme.Background)
+}
+
+// drawLabel draws text vertically centered at y.
+func drawLabel(img *image.NRGBA, face font.Face, text string, x, y int, col color.NRGBA) {
+	metrics := face.Metrics()
+	baseline := y + (metrics.Ascent-metrics.Descent).Ceil()/2
+
+	drawText(img, face, text, x, baseline, col)
+}
+
+func drawText(img *image.NRGBA, face font.Face, text string, x, baseline int, col color.NRGBA) {
+	drawer := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(col),
+		Face: face,
+		Dot:  fixed.P(x, baseline),
+	}
+
+	drawer.DrawString(text)
+}
+
+// formatPrice formats a price with four significant digits below 1, tiny
+// prices get the number of zeros after the point as a subscript: 0.0₅123.
+func formatPrice(value float64) string {
+	sign := ""
+	if value < 0 {
+		sign = "-"
+	}
+
+	abs := math.Abs(value)
+
+	switch {
+	case abs == 0:
+		return "0"
+	case abs >= 1000:
+		return sign + humanize.Commaf(math.Round(abs))
+	case abs >= 1:
+		return sign + strconv.FormatFloat(abs, 'f', 2, 64)
+	}
+
+	// the exponent of the rounded value, 1.230e-06 has five zeros
+	scientific := strconv.FormatFloat(abs, 'e', 3, 64)
+	mantissa, exponent, _ := strings.Cut(scientific, "e")
+	power, _ := strconv.Atoi(exponent)
+	zeros := -power - 1
+
+	if zeros < 0 {
+		return sign + "1"
+	}
+
+	if zeros < subscriptZeroes {
+		return sign + trimZeros(strconv.FormatFloat(abs, 'f', zeros+4, 64))
+	}
+
+	digits := strings.TrimRight(strings.Replace(mantissa, ".", "", 1), "0")
+
+	return sign + "0.0" + subscript(zeros) + digits
+}
+
+func trimZeros(value string) string {
+	if !strings.Contains(value, ".") {
+		return value
+	}
+
+	return strings.TrimSuffix(strings.TrimRight(value, "0"), ".")
+}
+
+func subscript(value int) string {
+	return strings.Map(func(r rune) rune {
+		return '₀' + r - '0'
+	}, strconv.Itoa(value))
+}
@@ -0,0 +1,63 @@
+package stickerUpdater
+
+import (
+	"image"
+	"testing"
+)
+
+func TestFormatPrice(t *testing.T) {
+	tests := map[float64]string{
+		0:            "0",
+		1234.56:      "1,235",
+		12.345:       "12.35",
+		0.5:          "0.5",
+		0.012345:     "0.01235",
+		0.00012346:   "0.0001235",
+		0.0000012346: "0.0₅1235",
+		0.0000000012: "0.0₈12",
+		-0.00001:     "-0.0₄1",
+		0.99999:      "1",
+	}
+
+	for value, expected := range tests {
+		if result := formatPrice(value); result != expected {
+			t.Errorf("%g: expected %q, but got %q", value, expected, result)
+		}
+	}
+}
+
+func TestCreateAxesWithLabels(t *testing.T) {
+	su, _ := testAnimationUpdater(t)
+
+	fonts := su.fonts.session("")
+	defer fonts.release()
+
+	face, err := fonts.face(defaultFont, defaultLabelSize)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	opts := Options{Width: 512, Height: 212, CandleWidth: 6, Columns: 20, Axes: Axes{PriceLabels: true, LastPrice: true, Face: face}}
+	candles := testCandles(24)
+
+	scale := newChartScale(candles, opts)
+	labelWidth := opts.Axes.priceLabelWidth(scale, opts.Rows, candles[len(candles)-1].Close)
+	if labelWidth <= 0 {
+		t.Fatalf("Expected space for price labels, but got %d", labelWidth)
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, 512, 212))
+	createAxes(img, candles, opts)
+
+	// the last price tag fills the right edge of the chart
+	tagged := false
+	for y := 0; y < 212; y++ {
+		if img.NRGBAAt(511, y).A != 0 {
+			tagged = true
+		}
+	}
+
+	if !tagged {
+		t.Errorf("Expected the last price tag at the right edge")
+	}
+}
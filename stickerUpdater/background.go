@@ -0,0 +1,169 @@
+package stickerUpdater
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // logo.jpg
+	_ "image/png"  // logo.png
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+	"golang.org/x/image/draw"
+)
+
+const (
+	stickerSize = 512
+
+	panelMargin    = 8
+	panelRadius    = 48
+	logoSize       = 44 // next to the token name of the default layout
+	logoX, logoY   = 16, 22
+	watermarkSize  = 320
+	watermarkAlpha = 20
+	boxRadius      = 16
+	svgTimeout     = 30 * time.Second
+)
+
+// errSVGConvert is returned when an SVG logo can't be rasterized.
+var errSVGConvert = errors.New("rsvg-convert")
+
+// logoFiles are looked up in the token directory in order.
+var logoFiles = []string{"logo.png", "logo.jpg", "logo.jpeg", "logo.webp", "logo.svg"}
+
+// infoBoxes are drawn under the 5M, 1H and 24H blocks of the default layout.
+var infoBoxes = []image.Rectangle{
+	image.Rect(14, 124, 166, 246),
+	image.Rect(174, 124, 318, 246),
+	image.Rect(326, 124, 498, 246),
+}
+
+// loadLogo decodes the logo of the token in dir, nil without one. SVG logos
+// are rasterized with rsvg-convert at svgPath, the error wraps errSVGConvert
+// when that fails.
+func loadLogo(dir, svgPath string) (image.Image, error) {
+	for _, name := range logoFiles {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.HasSuffix(name, ".svg") {
+			data, err = rasterizeSVG(svgPath, data, watermarkSize)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+
+		logo, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		return logo, nil
+	}
+
+	return nil, nil
+}
+
+// generateBackground draws a sticker template in the theme colors for tokens
+// without sticker.webp: a rounded panel with boxes under the info blocks of
+// the default layout, the logo next to the token name and faded in the middle.
+func generateBackground(theme *Theme, logo image.Image) image.Image {
+	dc := gg.NewContext(stickerSize, stickerSize)
+
+	gradient := gg.NewLinearGradient(0, 0, 0, stickerSize)
+	gradient.AddColorStop(0, mix(theme.Background, theme.Text, 0.08))
+	gradient.AddColorStop(1, theme.Background)
+
+	size := float64(stickerSize - 2*panelMargin)
+	dc.DrawRoundedRectangle(panelMargin, panelMargin, size, size, panelRadius)
+	dc.SetFillStyle(gradient)
+	dc.Fill()
+
+	dc.SetColor(theme.faded(defaultGridAlpha))
+	dc.SetLineWidth(2)
+	dc.DrawRoundedRectangle(panelMargin+1, panelMargin+1, size-2, size-2, panelRadius-1)
+	dc.Stroke()
+
+	dc.SetColor(theme.faded(watermarkAlpha / 2))
+	for _, box := range infoBoxes {
+		dc.DrawRoundedRectangle(float64(box.Min.X), float64(box.Min.Y), float64(box.Dx()), float64(box.Dy()), boxRadius)
+		dc.Fill()
+	}
+
+	if logo == nil {
+		return dc.Image()
+	}
+
+	img := dc.Image().(*image.RGBA)
+
+	drawRoundLogo(img, logo, image.Pt((stickerSize-watermarkSize)/2, (stickerSize-watermarkSize)/2), watermarkSize, watermarkAlpha)
+	drawRoundLogo(img, logo, image.Pt(logoX, logoY), logoSize, 0xff)
+
+	return img
+}
+
+// drawRoundLogo draws logo cropped to a circle of size at p with alpha.
+func drawRoundLogo(dst draw.Image, logo image.Image, p image.Point, size int, alpha uint8) {
+	// scale the logo to cover the circle, cropping the longer side
+	bounds := logo.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	crop := image.Rect(0, 0, side, side).Add(bounds.Min).Add(image.Pt((bounds.Dx()-side)/2, (bounds.Dy()-side)/2))
+
+	scaled := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), logo, crop, draw.Over, nil)
+
+	// antialiased circle: the coverage of a pixel is its distance to the edge
+	mask := image.NewAlpha(scaled.Bounds())
+	radius := float64(size) / 2
+
+	for y := range size {
+		for x := range size {
+			distance := math.Hypot(float64(x)+0.5-radius, float64(y)+0.5-radius)
+			coverage := math.Max(0, math.Min(1, radius-distance+0.5))
+			mask.SetAlpha(x, y, color.Alpha{A: uint8(coverage * float64(alpha))})
+		}
+	}
+
+	draw.DrawMask(dst, scaled.Bounds().Add(p), scaled, image.Point{}, mask, image.Point{}, draw.Over)
+}
+
+// mix blends b into a by share.
+func mix(a, b color.NRGBA, share float64) color.NRGBA {
+	channel := func(a, b uint8) uint8 {
+		return uint8(float64(a)*(1-share) + float64(b)*share)
+	}
+
+	return color.NRGBA{R: channel(a.R, b.R), G: channel(a.G, b.G), B: channel(a.B, b.B), A: channel(a.A, b.A)}
+}
+
+// rasterizeSVG renders an SVG to a size pixels wide PNG.
+func rasterizeSVG(svgPath string, input []byte, size int) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	rsvg := newBinWrapper(svgPath).
+		StdIn(bytes.NewReader(input)).
+		SetStdOut(buf).
+		Arg("--width", strconv.Itoa(size)).
+		Arg("--keep-aspect-ratio").
+		Arg("--format", "png").
+		Timeout(svgTimeout)
+
+	if err := rsvg.Run(); err != nil {
+		return nil, fmt.Errorf("%w: %w %s", errSVGConvert, err, strings.TrimSpace(string(rsvg.StdErr())))
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,99 @@
+package stickerUpdater
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadLogo(t *testing.T) {
+	dir := t.TempDir()
+
+	logo, err := loadLogo(dir, "")
+	if err != nil || logo != nil {
+		t.Fatalf("Expected no logo, but got %v (%v)", logo, err)
+	}
+
+	file, err := os.Create(filepath.Join(dir, "logo.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := png.Encode(file, testImage(64, 32)); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	logo, err = loadLogo(dir, "")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if logo.Bounds().Dx() != 64 || logo.Bounds().Dy() != 32 {
+		t.Errorf("Expected a 64x32 logo, but got %v", logo.Bounds())
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "logo.png"), []byte("not a png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loadLogo(dir, ""); err == nil {
+		t.Errorf("Expected error for a broken logo, but got nil")
+	}
+}
+
+func TestGenerateBackground(t *testing.T) {
+	logo := image.NewNRGBA(image.Rect(0, 0, 100, 50))
+	for i := range logo.Pix {
+		logo.Pix[i] = 0xff // white
+	}
+
+	theme := Themes["light"]
+
+	for _, background := range []image.Image{generateBackground(theme, nil), generateBackground(theme, logo)} {
+		if background.Bounds() != image.Rect(0, 0, stickerSize, stickerSize) {
+			t.Fatalf("Expected a %dx%d background, but got %v", stickerSize, stickerSize, background.Bounds())
+		}
+
+		// the rounded panel leaves the corners transparent
+		if _, _, _, a := background.At(0, 0).RGBA(); a != 0 {
+			t.Errorf("Expected a transparent corner, but got alpha %d", a)
+		}
+
+		if _, _, _, a := background.At(stickerSize/2, stickerSize-panelMargin-panelRadius).RGBA(); a != 0xffff {
+			t.Errorf("Expected an opaque panel, but got alpha %d", a)
+		}
+	}
+
+	// the logo is drawn in a circle next to the token name
+	center := color.NRGBAModel.Convert(generateBackground(Themes["dark"], logo).At(logoX+logoSize/2, logoY+logoSize/2)).(color.NRGBA)
+	if center.R != 0xff || center.G != 0xff || center.B != 0xff {
+		t.Errorf("Expected the white logo, but got %v", center)
+	}
+}
+
+func TestLoadTokenWithoutSVGConvert(t *testing.T) {
+	su := testAdminUpdater(t)
+	dir := su.config.TOKENS_PATH + "/anon"
+
+	writeToken(t, dir, `{"name": "Anon", "address": "pool"}`, time.Now())
+
+	if err := os.WriteFile(filepath.Join(dir, "logo.svg"), []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	su.config.SVG_CONVERT_PATH = "no-such-rsvg-convert"
+
+	stickerConfigs, err := su.loadToken(dir)
+	if err != nil {
+		t.Fatalf("Expected the token loaded without the logo, but got %v", err)
+	}
+
+	if stickerConfigs[0].image == nil {
+		t.Errorf("Expected a generated template")
+	}
+}
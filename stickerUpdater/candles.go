@@ -3,41 +3,11 @@ package stickerUpdater
 import (
 	"image"
 	"image/color"
-	"slices"
+	"math"
 	"strconv"
 	"time"
 )
 
-var (
-	POSITIVE_COLOR = color.RGBA{R: 126, G: 211, B: 33, A: 255}
-	NEGATIVE_COLOR = color.RGBA{R: 208, G: 2, B: 27, A: 255}
-	PIKE_COLOR     = color.RGBA{R: 211, G: 211, B: 211, A: 255}
-)
-
-type GeckoterminalOHLCVResponse struct {
-	Data struct {
-		ID         string `json:"id"`
-		Type       string `json:"type"`
-		Attributes struct {
-			OhlcvList [][]float64 `json:"ohlcv_list"`
-		} `json:"attributes"`
-	} `json:"data"`
-	Meta struct {
-		Base struct {
-			Address         string `json:"address"`
-			Name            string `json:"name"`
-			Symbol          string `json:"symbol"`
-			CoingeckoCoinID any    `json:"coingecko_coin_id"`
-		} `json:"base"`
-		Quote struct {
-			Address         string `json:"address"`
-			Name            string `json:"name"`
-			Symbol          string `json:"symbol"`
-			CoingeckoCoinID any    `json:"coingecko_coin_id"`
-		} `json:"quote"`
-	} `json:"meta"`
-}
-
 type Candle struct {
 	Time   time.Time
 	Open   float64
@@ -47,67 +17,43 @@ type Candle struct {
 	Volume float64
 }
 
-func getOHLCVData(dataURL string) (GeckoterminalOHLCVResponse, error) {
-	var data GeckoterminalOHLCVResponse
-
-	err := getJson(dataURL, &data)
-	if err != nil {
-		return data, err
-	}
-
-	return data, nil
-}
-
-func getCandles(data GeckoterminalOHLCVResponse) []Candle {
-	var ohlcvData []Candle
-
-	for _, ohlcv := range data.Data.Attributes.OhlcvList {
-		ohlcvData = append(ohlcvData, Candle{
-			Time:   time.Unix(int64(ohlcv[0]), 0),
-			Open:   ohlcv[1],
-			High:   ohlcv[2],
-			Low:    ohlcv[3],
-			Close:  ohlcv[4],
-			Volume: ohlcv[5],
-		})
-	}
-
-	slices.Reverse(ohlcvData)
-
-	return ohlcvData
-}
-
-func (c Candle) getColor() color.RGBA {
-	if c.Open > c.Close {
-		return NEGATIVE_COLOR
-	}
-
-	return POSITIVE_COLOR
-}
-
 type Options struct {
+	XOffset     int
 	Width       int
 	Height      int
 	YOffset     int
 	CandleWidth int
 	Columns     int
 	Rows        int
+	Visible     int // candles drawn from the start of data, all when 0
+	ChartType   string
+	Volume      *VolumePanel
+	Overlays    []Overlay
+	Axes        Axes
+	Theme       *Theme // the default theme when nil
 }
 
-func createAxes(img *image.NRGBA, data []Candle, opts Options) {
+// chartScale maps candle times and prices to chart pixels.
+type chartScale struct {
+	lowerValue        float64
+	higherValue       float64
+	chartHmin         int
+	chartHmax         int
+	startTimePosition int
+	endTimePosition   int
+	startTime         time.Time
+	timeDiff          time.Duration
+}
+
+func newChartScale(data []Candle, opts Options) chartScale {
 	var (
-		higherValue       float64
-		lowerValue        float64
-		chartHmin         int
-		chartHmax         int
+		scale             chartScale
 		startTimePosition int
 		endTimePosition   int
-		startTime         time.Time
-		timeDiff          time.Duration
 	)
 
-	chartHmin = opts.Height
-	chartHmax = 5 + opts.YOffset
+	scale.chartHmin = opts.Height
+	scale.chartHmax = 5 + opts.YOffset
 	chartWmax := opts.Width - 5
 
 	var max float64
@@ -137,17 +83,21 @@ func createAxes(img *image.NRGBA, data []Candle, opts Options) {
 	chartLineWidth := 5
 
 	diff := ((max - min) * 5) / 100
-	higherValue = max + diff
-	lowerValue = min - diff
+	if diff == 0 {
+		// a flat series is drawn in the middle of the chart
+		diff = math.Max(math.Abs(max)*0.05, 1e-12)
+	}
+	scale.higherValue = max + diff
+	scale.lowerValue = min - diff
 
-	startTime = data[0].Time
+	scale.startTime = data[0].Time
 	endTime := data[len(data)-1].Time
 
-	timeDiff = endTime.Sub(startTime)
+	scale.timeDiff = endTime.Sub(scale.startTime)
 
-	separation := (chartWmax - chartSeparation) / opts.Columns
+	separation := (chartWmax - opts.XOffset - chartSeparation) / opts.Columns
 	for i := 0; i < opts.Columns+1; i++ {
-		xPosition := chartSeparation + chartLineWidth + separation*i
+		xPosition := opts.XOffset + chartSeparation + chartLineWidth + separation*i
 		if i == 0 {
 			startTimePosition = xPosition
 		} else if i == opts.Columns {
@@ -155,30 +105,114 @@ func createAxes(img *image.NRGBA, data []Candle, opts Options) {
 		}
 	}
 
+	scale.startTimePosition = startTimePosition
+	scale.endTimePosition = endTimePosition
+
+	return scale
+}
+
+func (s chartScale) x(t time.Time) int {
+	if s.timeDiff <= 0 {
+		return (s.startTimePosition + s.endTimePosition) / 2
+	}
+
+	return getXPointInChart(t, s.startTime, s.timeDiff, s.startTimePosition, s.endTimePosition)
+}
+
+func (s chartScale) y(value float64) int {
+	return getYPointInChart(value, s.lowerValue, s.higherValue, s.chartHmin, s.chartHmax)
+}
+
+// createAxes draws data as opts.ChartType, candlesticks by default.
+func createAxes(img *image.NRGBA, data []Candle, opts Options) {
+	if len(data) == 0 {
+		return
+	}
+
+	if opts.Theme == nil {
+		opts.Theme = Themes[DefaultTheme]
+	}
+
+	raw := data
+	if opts.ChartType == ChartHeikinAshi {
+		data = heikinAshi(data)
+	}
+
+	visible := len(data)
+	if opts.Visible > 0 && opts.Visible < len(data) {
+		visible = opts.Visible
+	}
+
+	// time labels take the bottom of the chart, the volume panel is above them
+	bottom := opts.Height - opts.Axes.timeLabelHeight()
+	volume := volumeHeight(opts)
+
+	priceOpts := opts
+	priceOpts.Height = bottom - volume
+
+	// the scale covers all candles, so it doesn't change while they are drawn in
+	scale := newChartScale(data, priceOpts)
+
+	// price labels take the right of the chart
+	if labelWidth := opts.Axes.priceLabelWidth(scale, opts.Rows, raw[len(raw)-1].Close); labelWidth > 0 {
+		priceOpts.Width -= labelWidth
+		scale = newChartScale(data, priceOpts)
+	}
+
+	if opts.Axes.Grid || opts.Axes.PriceLabels {
+		drawGrid(img, scale, opts, priceOpts.Width)
+	}
+
+	if opts.Volume != nil {
+		drawVolume(img, raw, visible, scale, opts, bottom, volume)
+	}
+
+	switch opts.ChartType {
+	case ChartLine:
+		drawLineChart(img, data[:visible], scale, opts.Theme, false)
+	case ChartArea:
+		drawLineChart(img, data[:visible], scale, opts.Theme, true)
+	case ChartOHLC:
+		drawOHLCBars(img, data[:visible], scale, opts)
+	default:
+		drawCandles(img, data[:visible], scale, opts)
+	}
+
+	drawOverlays(img, raw, visible, scale, opts.Overlays)
+
+	if opts.Axes.TimeLabels {
+		drawTimeLabels(img, raw, scale, opts, bottom)
+	}
+
+	if opts.Axes.LastPrice {
+		drawLastPrice(img, raw[visible-1], scale, opts, priceOpts.Width)
+	}
+}
+
+func drawCandles(img *image.NRGBA, data []Candle, scale chartScale, opts Options) {
 	for _, d := range data {
-		t := d.Time
-		newXPosition := getXPointInChart(t, startTime, timeDiff, startTimePosition, endTimePosition)
-		candleColor := d.getColor()
-		candleHighYPosition := getYPointInChart(d.High, lowerValue, higherValue, chartHmin, chartHmax)
-		candleOpenYpoint := getYPointInChart(d.Open, lowerValue, higherValue, chartHmin, chartHmax)
-		candleCloseYpoint := getYPointInChart(d.Close, lowerValue, higherValue, chartHmin, chartHmax)
-		candleLowYpoint := getYPointInChart(d.Low, lowerValue, higherValue, chartHmin, chartHmax)
-
-		if candleColor == POSITIVE_COLOR {
+		newXPosition := scale.x(d.Time)
+		candleColor := opts.Theme.candleColor(d.Open, d.Close)
+		candleHighYPosition := scale.y(d.High)
+		candleOpenYpoint := scale.y(d.Open)
+		candleCloseYpoint := scale.y(d.Close)
+		candleLowYpoint := scale.y(d.Low)
+
+		if d.Open < d.Close {
 			aux := candleCloseYpoint
 			candleCloseYpoint = candleOpenYpoint
 			candleOpenYpoint = aux
 
 		}
 
-		line(newXPosition, newXPosition, candleHighYPosition, candleOpenYpoint, PIKE_COLOR, img)
+		line(newXPosition, newXPosition, candleHighYPosition, candleOpenYpoint, opts.Theme.Wick, img)
 		halfCandleWidth := opts.CandleWidth / 2
 		line(newXPosition-halfCandleWidth, newXPosition+halfCandleWidth, candleOpenYpoint, candleCloseYpoint, candleColor, img)
-		line(newXPosition, newXPosition, candleCloseYpoint, candleLowYpoint, PIKE_COLOR, img)
+		line(newXPosition, newXPosition, candleCloseYpoint, candleLowYpoint, opts.Theme.Wick, img)
 	}
 }
 
-func line(x1, x2, y1, y2 int, col color.RGBA, img *image.NRGBA) {
+func line(x1, x2, y1, y2 int, col color.Color, img *image.NRGBA) {
 	for y := y1; y <= y2; y++ {
 		for x := x1; x <= x2; x++ {
 			img.Set(x, y, col)
@@ -0,0 +1,174 @@
+package stickerUpdater
+
+import (
+	"image"
+	"image/color"
+	"slices"
+)
+
+const (
+	ChartCandles    = "candles"
+	ChartLine       = "line"
+	ChartArea       = "area" // line filled with a gradient down to the chart bottom
+	ChartOHLC       = "ohlc"
+	ChartHeikinAshi = "heikin-ashi"
+
+	chartLineWidth = 2
+)
+
+var chartTypes = []string{"", ChartCandles, ChartLine, ChartArea, ChartOHLC, ChartHeikinAshi}
+
+func validChartType(chartType string) bool {
+	return slices.Contains(chartTypes, chartType)
+}
+
+// heikinAshi returns the Heikin-Ashi candles of data, which smooth out the
+// noise of sparse trading.
+func heikinAshi(data []Candle) []Candle {
+	result := make([]Candle, len(data))
+
+	for i, d := range data {
+		ha := Candle{Time: d.Time, Volume: d.Volume}
+		ha.Close = (d.Open + d.High + d.Low + d.Close) / 4
+
+		if i == 0 {
+			ha.Open = (d.Open + d.Close) / 2
+		} else {
+			ha.Open = (result[i-1].Open + result[i-1].Close) / 2
+		}
+
+		ha.High = max(d.High, ha.Open, ha.Close)
+		ha.Low = min(d.Low, ha.Open, ha.Close)
+
+		result[i] = ha
+	}
+
+	return result
+}
+
+// drawLineChart connects the close prices, the whole series is colored by
+// the change from the first open to the last close.
+func drawLineChart(img *image.NRGBA, data []Candle, scale chartScale, theme *Theme, fill bool) {
+	lineColor := theme.candleColor(data[0].Open, data[len(data)-1].Close)
+
+	points := make([]image.Point, len(data))
+	for i, d := range data {
+		points[i] = image.Point{X: scale.x(d.Time), Y: scale.y(d.Close)}
+	}
+
+	if fill {
+		fillArea(img, points, scale.chartHmin, lineColor)
+	}
+
+	if len(points) == 1 {
+		dot(img, points[0], chartLineWidth+1, lineColor)
+		return
+	}
+
+	for i := 1; i < len(points); i++ {
+		thickLine(img, points[i-1], points[i], chartLineWidth, lineColor)
+	}
+}
+
+// fillArea fills the area under the line with lineColor fading out to the
+// bottom of the chart.
+func fillArea(img *image.NRGBA, points []image.Point, bottom int, lineColor color.NRGBA) {
+	top := bottom
+	for _, p := range points {
+		top = min(top, p.Y)
+	}
+
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		if a.X == b.X {
+			continue
+		}
+
+		// shared points belong to the next segment, so they're not filled twice
+		last := b.X - 1
+		if i == len(points)-1 {
+			last = b.X
+		}
+
+		for x := a.X; x <= last; x++ {
+			y := a.Y + (b.Y-a.Y)*(x-a.X)/(b.X-a.X)
+
+			for yy := y; yy <= bottom; yy++ {
+				fade := 1 - float64(yy-top)/float64(max(bottom-top, 1))
+				blend(img, x, yy, color.NRGBA{R: lineColor.R, G: lineColor.G, B: lineColor.B, A: uint8(160 * fade)})
+			}
+		}
+	}
+}
+
+func drawOHLCBars(img *image.NRGBA, data []Candle, scale chartScale, opts Options) {
+	tick := max(opts.CandleWidth/2, 1)
+
+	for _, d := range data {
+		x := scale.x(d.Time)
+		barColor := opts.Theme.candleColor(d.Open, d.Close)
+
+		line(x, x, scale.y(d.High), scale.y(d.Low), barColor, img)
+		line(x-tick, x, scale.y(d.Open), scale.y(d.Open), barColor, img)
+		line(x, x+tick, scale.y(d.Close), scale.y(d.Close), barColor, img)
+	}
+}
+
+// thickLine draws a line of width pixels from a to b.
+func thickLine(img *image.NRGBA, a, b image.Point, width int, col color.Color) {
+	dx, dy := abs(b.X-a.X), -abs(b.Y-a.Y)
+	sx, sy := 1, 1
+	if a.X > b.X {
+		sx = -1
+	}
+	if a.Y > b.Y {
+		sy = -1
+	}
+
+	err := dx + dy
+	for p := a; ; {
+		dot(img, p, width, col)
+
+		if p == b {
+			return
+		}
+
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			p.X += sx
+		}
+		if e2 <= dx {
+			err += dx
+			p.Y += sy
+		}
+	}
+}
+
+func dot(img *image.NRGBA, p image.Point, size int, col color.Color) {
+	half := size / 2
+	line(p.X-half, p.X-half+size-1, p.Y-half, p.Y-half+size-1, col, img)
+}
+
+// blend draws col over the pixel at x, y.
+func blend(img *image.NRGBA, x, y int, col color.NRGBA) {
+	if !(image.Point{X: x, Y: y}.In(img.Rect)) {
+		return
+	}
+
+	dst := img.NRGBAAt(x, y)
+
+	srcA := float64(col.A) / 0xff
+	dstA := float64(dst.A) / 0xff
+	outA := srcA + dstA*(1-srcA)
+
+	if outA == 0 {
+		return
+	}
+
+	mix := func(src, dst uint8) uint8 {
+		return uint8((float64(src)*srcA + float64(dst)*dstA*(1-srcA)) / outA)
+	}
+
+	img.SetNRGBA(x, y, color.NRGBA{R: mix(col.R, dst.R), G: mix(col.G, dst.G), B: mix(col.B, dst.B), A: uint8(outA * 0xff)})
+}
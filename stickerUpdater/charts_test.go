@@ -0,0 +1,54 @@
+package stickerUpdater
+
+import (
+	"image"
+	"testing"
+	"time"
+)
+
+func TestHeikinAshi(t *testing.T) {
+	data := []Candle{
+		{Open: 10, High: 14, Low: 8, Close: 12},
+		{Open: 12, High: 16, Low: 11, Close: 15},
+	}
+
+	result := heikinAshi(data)
+
+	if result[0].Open != 11 || result[0].Close != 11 {
+		t.Errorf("Expected first candle open and close 11, but got %+v", result[0])
+	}
+
+	if result[1].Open != 11 || result[1].Close != 13.5 || result[1].High != 16 || result[1].Low != 11 {
+		t.Errorf("Expected second candle 11/16/11/13.5, but got %+v", result[1])
+	}
+}
+
+func TestCreateAxesChartTypes(t *testing.T) {
+	for _, chartType := range chartTypes {
+		img := image.NewNRGBA(image.Rect(0, 0, 512, 212))
+
+		createAxes(img, testCandles(24), Options{Width: 512, Height: 212, CandleWidth: 6, Columns: 20, ChartType: chartType})
+
+		drawn := 0
+		for i := 3; i < len(img.Pix); i += 4 {
+			if img.Pix[i] != 0 {
+				drawn++
+			}
+		}
+
+		if drawn == 0 {
+			t.Errorf("%q: expected the chart to be drawn", chartType)
+		}
+	}
+}
+
+func TestCreateAxesSingleCandle(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 512, 212))
+
+	// a single flat candle has neither a time nor a price range
+	candles := []Candle{{Time: time.Unix(0, 0), Open: 1, High: 1, Low: 1, Close: 1}}
+
+	for _, chartType := range chartTypes {
+		createAxes(img, candles, Options{Width: 512, Height: 212, CandleWidth: 6, Columns: 20, ChartType: chartType})
+	}
+}
@@ -0,0 +1,397 @@
+package stickerUpdater
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"image"
+	"image/draw"
+	"image/png"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ad/anonstickerbot/config"
+	"github.com/ad/anonstickerbot/sender"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	chartWidth   = 1024
+	chartHeight  = 512
+	chartCandles = 60
+)
+
+// chartLayout is the layout of /chart images: the chart with axes and no
+// text blocks on a plain background.
+var chartLayout = &Layout{
+	Charts: []ChartRegion{{
+		X:           16,
+		Y:           16,
+		Width:       chartWidth - 32,
+		Height:      chartHeight - 32,
+		CandleWidth: 10,
+		Columns:     chartCandles,
+		Grid:        true,
+		PriceLabels: true,
+		TimeLabels:  true,
+		LastPrice:   true,
+		LabelSize:   16,
+	}},
+}
+
+// RegisterCommands adds the chat commands about the tokens to the sender:
+//
+//	/price <token>             the sticker and a short summary
+//	/chart <token> [timeframe] a chart image, 15m candles by default
+//	/stats <token>             volumes, transactions and price changes
+//
+// and the admin commands managing the tokens.
+func (su *StickerUpdater) RegisterCommands() {
+	su.sender.RegisterCommand("price", su.priceCommand)
+	su.sender.RegisterCommand("chart", su.chartCommand)
+	su.sender.RegisterCommand("stats", su.statsCommand)
+
+	su.registerAdminCommands()
+}
+
+func (su *StickerUpdater) priceCommand(ctx context.Context, b *bot.Bot, message *models.Message, args []string) {
+	stickerConfig, ok := su.commandSticker(ctx, b, message, args, "/price <token>")
+	if !ok {
+		return
+	}
+
+	data, candles, err := su.snapshot(ctx, stickerConfig)
+	if err != nil {
+		su.commandError(ctx, b, message, stickerConfig, err)
+		return
+	}
+
+	su.sender.RLock()
+	lastSticker, sent := su.sender.LastStickers[stickerConfig.Name]
+	su.sender.RUnlock()
+
+	var sticker models.InputFile = &models.InputFileString{Data: lastSticker.FileID}
+
+	// not sent to the target chat yet
+	if !sent {
+		stickerData, filename, err := su.encodeSticker(ctx, stickerConfig, data, candles)
+		if err != nil {
+			su.commandError(ctx, b, message, stickerConfig, err)
+			return
+		}
+
+		sticker = &models.InputFileUpload{Filename: filename, Data: bytes.NewReader(stickerData)}
+	}
+
+	sendCtx, cancel := config.WithTimeout(ctx, su.config.TELEGRAM_TIMEOUT)
+	defer cancel()
+
+	if _, err := b.SendSticker(sendCtx, &bot.SendStickerParams{
+		ChatID:          message.Chat.ID,
+		Sticker:         sticker,
+		Emoji:           stickerConfig.Emoji,
+		ReplyParameters: replyTo(message),
+	}); err != nil {
+		su.logger.Error(fmt.Sprintf("%s /price sticker error: %s", stickerConfig.Name, err))
+	}
+
+	su.reply(ctx, b, message, priceSummary(data, su.chatLocale(stickerConfig, message.Chat.ID)))
+}
+
+func (su *StickerUpdater) chartCommand(ctx context.Context, b *bot.Bot, message *models.Message, args []string) {
+	stickerConfig, ok := su.commandSticker(ctx, b, message, args, "/chart <token> [timeframe]")
+	if !ok {
+		return
+	}
+
+	timeframe := stickerConfig.candleQuery().Timeframe
+	if len(args) > 1 {
+		timeframe = strings.ToLower(args[1])
+	}
+
+	if _, ok := Timeframes[timeframe]; !ok {
+		su.reply(ctx, b, message, fmt.Sprintf("Unknown timeframe %s, use %s", html.EscapeString(timeframe), strings.Join(timeframeNames(), ", ")))
+		return
+	}
+
+	var userID int64
+	if message.From != nil {
+		userID = message.From.ID
+	}
+
+	candles, err := su.chartCandles(ctx, userID, stickerConfig, timeframe)
+
+	var rateLimitError *sender.RateLimitError
+	if errors.As(err, &rateLimitError) {
+		su.reply(ctx, b, message, fmt.Sprintf("Too many charts, try again in %s", rateLimitError.RetryAfter.Round(time.Second)))
+		return
+	}
+
+	if err != nil {
+		su.commandError(ctx, b, message, stickerConfig, err)
+		return
+	}
+
+	chart, err := su.renderChart(stickerConfig, timeframe, candles, su.chatLocale(stickerConfig, message.Chat.ID))
+	if err != nil {
+		su.commandError(ctx, b, message, stickerConfig, err)
+		return
+	}
+
+	sendCtx, cancel := config.WithTimeout(ctx, su.config.TELEGRAM_TIMEOUT)
+	defer cancel()
+
+	if _, err := b.SendPhoto(sendCtx, &bot.SendPhotoParams{
+		ChatID:          message.Chat.ID,
+		Photo:           &models.InputFileUpload{Filename: "chart.png", Data: bytes.NewReader(chart)},
+		Caption:         fmt.Sprintf("%s, %s", stickerConfig.Name, timeframe),
+		ReplyParameters: replyTo(message),
+	}); err != nil {
+		su.logger.Error(fmt.Sprintf("%s /chart error: %s", stickerConfig.Name, err))
+	}
+}
+
+func (su *StickerUpdater) statsCommand(ctx context.Context, b *bot.Bot, message *models.Message, args []string) {
+	stickerConfig, ok := su.commandSticker(ctx, b, message, args, "/stats <token>")
+	if !ok {
+		return
+	}
+
+	data, _, err := su.snapshot(ctx, stickerConfig)
+	if err != nil {
+		su.commandError(ctx, b, message, stickerConfig, err)
+		return
+	}
+
+	su.reply(ctx, b, message, statsText(data, su.chatLocale(stickerConfig, message.Chat.ID)))
+}
+
+// commandSticker finds the sticker named by the first argument and replies
+// with the usage or the lookup error when there is none.
+func (su *StickerUpdater) commandSticker(ctx context.Context, b *bot.Bot, message *models.Message, args []string, usage string) (*StickerConfig, bool) {
+	if len(args) == 0 {
+		su.reply(ctx, b, message, html.EscapeString("Usage: "+usage))
+		return nil, false
+	}
+
+	stickerConfig, err := su.findSticker(args[0])
+	if err != nil {
+		su.reply(ctx, b, message, html.EscapeString(err.Error()))
+		return nil, false
+	}
+
+	return stickerConfig, true
+}
+
+func (su *StickerUpdater) commandError(ctx context.Context, b *bot.Bot, message *models.Message, stickerConfig *StickerConfig, err error) {
+	su.logger.Error(fmt.Sprintf("%s command error: %s", stickerConfig.Name, err))
+	su.reply(ctx, b, message, html.EscapeString(fmt.Sprintf("No data for %s, try again later", stickerConfig.Name)))
+}
+
+// reply sends HTML text in reply to message.
+func (su *StickerUpdater) reply(ctx context.Context, b *bot.Bot, message *models.Message, text string) {
+	sendCtx, cancel := config.WithTimeout(ctx, su.config.TELEGRAM_TIMEOUT)
+	defer cancel()
+
+	if _, err := b.SendMessage(sendCtx, &bot.SendMessageParams{
+		ChatID:             message.Chat.ID,
+		Text:               text,
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: &models.LinkPreviewOptions{IsDisabled: bot.True()},
+		ReplyParameters:    replyTo(message),
+	}); err != nil {
+		su.logger.Error(fmt.Sprintf("reply to %d error: %s", message.Chat.ID, err))
+	}
+}
+
+func replyTo(message *models.Message) *models.ReplyParameters {
+	return &models.ReplyParameters{MessageID: message.ID, AllowSendingWithoutReply: true}
+}
+
+// chatLocale is the CHAT_LOCALES locale of the chat, the token locale by
+// default.
+func (su *StickerUpdater) chatLocale(stickerConfig *StickerConfig, chatID int64) *Locale {
+	if locale, ok := Locales[su.config.ChatLocales[chatID]]; ok {
+		return locale
+	}
+
+	locale, _ := stickerConfig.localization()
+
+	return locale
+}
+
+// chartCandles returns the candles of timeframe for /chart, cached per
+// sticker and timeframe for the sticker interval. A user may start
+// CHART_RATE_LIMIT uncached fetches per CHART_RATE_WINDOW.
+func (su *StickerUpdater) chartCandles(ctx context.Context, userID int64, stickerConfig *StickerConfig, timeframe string) ([]Candle, error) {
+	now := time.Now()
+
+	su.stateMutex.Lock()
+
+	cached, ok := su.charts[stickerConfig.Name][timeframe]
+	if ok && now.Sub(cached.fetched) < su.interval(stickerConfig) {
+		su.stateMutex.Unlock()
+		return cached.candles, nil
+	}
+
+	if retryAfter := su.allowChart(userID, now); retryAfter > 0 {
+		su.stateMutex.Unlock()
+		return nil, &sender.RateLimitError{RetryAfter: retryAfter}
+	}
+
+	su.stateMutex.Unlock()
+
+	chartConfig := *stickerConfig
+	chartConfig.Timeframe = timeframe
+	chartConfig.Candles = chartCandles
+	chartConfig.command = true
+
+	provider, err := su.providerFor(&chartConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	candles, err := provider.GetCandles(ctx, chartConfig.Address, chartConfig.candleQuery())
+	if err != nil {
+		return nil, fmt.Errorf("%s:%s %s getCandles error: %w", chartConfig.Name, chartConfig.Address, provider.Name(), err)
+	}
+
+	if len(candles) == 0 {
+		return nil, fmt.Errorf("%s:%s no candles", chartConfig.Name, chartConfig.Address)
+	}
+
+	su.stateMutex.Lock()
+	defer su.stateMutex.Unlock()
+
+	// the sticker may be gone while fetching
+	if _, ok := su.stickers[stickerConfig.Name]; ok {
+		if su.charts[stickerConfig.Name] == nil {
+			su.charts[stickerConfig.Name] = make(map[string]tokenSnapshot)
+		}
+
+		su.charts[stickerConfig.Name][timeframe] = tokenSnapshot{candles: candles, fetched: now}
+	}
+
+	return candles, nil
+}
+
+// allowChart records a /chart fetch of userID and returns 0, or how long the
+// user has to wait when the limit is reached. stateMutex must be held.
+func (su *StickerUpdater) allowChart(userID int64, now time.Time) time.Duration {
+	if su.config.CHART_RATE_LIMIT <= 0 {
+		return 0
+	}
+
+	window := time.Duration(su.config.CHART_RATE_WINDOW) * time.Second
+
+	for user, fetches := range su.chartUsers {
+		i := 0
+		for i < len(fetches) && !now.Before(fetches[i].Add(window)) {
+			i++
+		}
+
+		if i == len(fetches) {
+			delete(su.chartUsers, user)
+		} else {
+			su.chartUsers[user] = fetches[i:]
+		}
+	}
+
+	fetches := su.chartUsers[userID]
+	if len(fetches) >= su.config.CHART_RATE_LIMIT {
+		return fetches[0].Add(window).Sub(now)
+	}
+
+	su.chartUsers[userID] = append(fetches, now)
+
+	return 0
+}
+
+// renderChart renders the candles of timeframe as a PNG with axes.
+func (su *StickerUpdater) renderChart(stickerConfig *StickerConfig, timeframe string, candles []Candle, locale *Locale) ([]byte, error) {
+	chartConfig := *stickerConfig
+	chartConfig.Timeframe = timeframe
+	chartConfig.Candles = chartCandles
+	chartConfig.layout = chartLayout
+	chartConfig.locale = locale
+
+	background := image.NewNRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	draw.Draw(background, background.Bounds(), image.NewUniform(chartConfig.palette().Background), image.Point{}, draw.Src)
+	chartConfig.image = background
+
+	img, err := su.drawCharts(background, &chartConfig, candles, len(candles))
+	if err != nil {
+		return nil, fmt.Errorf("%s:%s render error: %w", chartConfig.Name, chartConfig.Address, err)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func timeframeNames() []string {
+	names := make([]string, 0, len(Timeframes))
+	for name := range Timeframes {
+		names = append(names, name)
+	}
+
+	slices.SortFunc(names, func(a, b string) int {
+		return int(Timeframes[a] - Timeframes[b])
+	})
+
+	return names
+}
+
+// priceSummary is the /price text: the pool, its price and the 24h change.
+func priceSummary(data PoolSnapshot, locale *Locale) string {
+	return fmt.Sprintf(
+		"<b>%s</b>\n$%s %s 24h\nFDV $%s, liquidity $%s, volume 24h $%s",
+		html.EscapeString(data.Name),
+		locale.price(data.PriceUSD),
+		locale.change(data.H24.PriceChange),
+		locale.compact(data.FdvUSD),
+		locale.compact(data.ReserveUSD),
+		locale.compact(data.H24.Volume),
+	)
+}
+
+// statsText is the /stats text: the pool totals and a table of the windows.
+func statsText(data PoolSnapshot, locale *Locale) string {
+	buf := new(strings.Builder)
+	table := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(table, "Price\t$%s\n", locale.price(data.PriceUSD))
+	fmt.Fprintf(table, "FDV\t$%s\n", locale.compact(data.FdvUSD))
+	fmt.Fprintf(table, "Liquidity\t$%s\n", locale.compact(data.ReserveUSD))
+	table.Flush()
+
+	buf.WriteString("\n")
+
+	table = tabwriter.NewWriter(buf, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(table, "\tChange\tVolume\tBuys\tSells\t\n")
+
+	for _, window := range []struct {
+		name string
+		Window
+	}{{"5m", data.M5}, {"1h", data.H1}, {"6h", data.H6}, {"24h", data.H24}} {
+		buys, sells := locale.comma(window.Buys), locale.comma(window.Sells)
+		if window.Buys == 0 && window.Sells == 0 {
+			// not every provider counts transactions of every window
+			buys, sells = "-", "-"
+		}
+
+		fmt.Fprintf(table, "%s\t%s\t$%s\t%s\t%s\t\n", window.name, locale.change(window.PriceChange), locale.compact(window.Volume), buys, sells)
+	}
+
+	table.Flush()
+
+	return fmt.Sprintf("<b>%s</b>\n<pre>%s</pre>", html.EscapeString(data.Name), html.EscapeString(strings.TrimRight(buf.String(), "\n")))
+}
@@ -0,0 +1,200 @@
+package stickerUpdater
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ad/anonstickerbot/config"
+	"github.com/ad/anonstickerbot/sender"
+)
+
+// fixtureProvider serves the preview fixture and golden candles.
+type fixtureProvider struct {
+	fixture *Fixture
+	pools   int
+	queries []CandleQuery
+}
+
+func (p *fixtureProvider) Name() string {
+	return "fixture"
+}
+
+func (p *fixtureProvider) GetPool(ctx context.Context, address string) (PoolSnapshot, error) {
+	p.pools++
+	return p.fixture.Pool, nil
+}
+
+func (p *fixtureProvider) GetCandles(ctx context.Context, address string, query CandleQuery) ([]Candle, error) {
+	p.queries = append(p.queries, query)
+	return goldenCandles(query.Limit), nil
+}
+
+func testCommandsUpdater(t *testing.T) (*StickerUpdater, *fixtureProvider) {
+	t.Helper()
+
+	fixture, err := LoadFixture("testdata/preview_fixture.json")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	provider := &fixtureProvider{fixture: fixture}
+
+	su := &StickerUpdater{
+		config:        &config.Config{DATA_PROVIDER: "fixture", UPDATE_DELAY: 60, CHART_RATE_LIMIT: 2, CHART_RATE_WINDOW: 600, ChatLocales: map[int64]string{-100: "ru"}},
+		providers:     map[string]MarketDataProvider{"fixture": provider},
+		health:        NewHealthTracker(time.Minute),
+		commandHealth: NewHealthTracker(time.Minute),
+		fonts:         NewFontCache(""),
+		snapshots:     make(map[string]tokenSnapshot),
+		charts:        make(map[string]map[string]tokenSnapshot),
+		chartUsers:    make(map[int64][]time.Time),
+	}
+
+	return su, provider
+}
+
+func TestSnapshotCache(t *testing.T) {
+	su, provider := testCommandsUpdater(t)
+	stickerConfig := &StickerConfig{Name: "Anon", Address: "pool"}
+
+	for range 2 {
+		data, _, err := su.snapshot(context.Background(), stickerConfig)
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		if data.Name != "ANON / TON" {
+			t.Errorf("Expected ANON / TON, but got %s", data.Name)
+		}
+	}
+
+	if provider.pools != 1 {
+		t.Errorf("Expected 1 fetch, but got %d", provider.pools)
+	}
+
+	// older than the interval
+	su.snapshots["Anon"] = tokenSnapshot{fetched: time.Now().Add(-time.Minute)}
+
+	if _, _, err := su.snapshot(context.Background(), stickerConfig); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if provider.pools != 2 {
+		t.Errorf("Expected 2 fetches, but got %d", provider.pools)
+	}
+}
+
+func TestRenderChart(t *testing.T) {
+	su, provider := testCommandsUpdater(t)
+	stickerConfig := &StickerConfig{Name: "Anon", Address: "pool", theme: Themes["light"]}
+
+	candles, err := su.chartCandles(context.Background(), 1, stickerConfig, "4h")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	data, err := su.renderChart(stickerConfig, "4h", candles, su.chatLocale(stickerConfig, 1))
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if bounds := img.Bounds(); bounds.Dx() != chartWidth || bounds.Dy() != chartHeight {
+		t.Errorf("Expected %dx%d, but got %v", chartWidth, chartHeight, bounds)
+	}
+
+	if len(provider.queries) != 1 || provider.queries[0] != (CandleQuery{Timeframe: "4h", Limit: chartCandles}) {
+		t.Errorf("Expected 4h candles, but got %v", provider.queries)
+	}
+
+	if stickerConfig.Timeframe != "" || stickerConfig.layout != nil {
+		t.Errorf("Expected the sticker to stay unchanged")
+	}
+}
+
+func TestChartCandles(t *testing.T) {
+	su, provider := testCommandsUpdater(t)
+	stickerConfig := &StickerConfig{Name: "Anon", Address: "pool"}
+	su.stickers = map[string]*StickerConfig{"Anon": stickerConfig}
+
+	for _, timeframe := range []string{"1h", "1h", "4h"} {
+		if _, err := su.chartCandles(context.Background(), 1, stickerConfig, timeframe); err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+	}
+
+	if len(provider.queries) != 2 {
+		t.Errorf("Expected the 1h candles fetched once, but got %v", provider.queries)
+	}
+
+	// cached candles are not limited
+	if _, err := su.chartCandles(context.Background(), 1, stickerConfig, "4h"); err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+
+	var rateLimitError *sender.RateLimitError
+	if _, err := su.chartCandles(context.Background(), 1, stickerConfig, "1d"); !errors.As(err, &rateLimitError) {
+		t.Errorf("Expected a rate limit error, but got %v", err)
+	}
+
+	if _, err := su.chartCandles(context.Background(), 2, stickerConfig, "1d"); err != nil {
+		t.Errorf("Expected no limit of another user, but got %v", err)
+	}
+
+	if len(su.health.health) != 0 || len(su.commandHealth.health) != 1 {
+		t.Errorf("Expected the health of the commands only, but got %s and %s", su.health, su.commandHealth)
+	}
+}
+
+func TestChatLocale(t *testing.T) {
+	su, _ := testCommandsUpdater(t)
+	stickerConfig := &StickerConfig{locale: Locales["de"]}
+
+	if su.chatLocale(stickerConfig, -100) != Locales["ru"] {
+		t.Errorf("Expected the ru locale of the chat")
+	}
+
+	if su.chatLocale(stickerConfig, 1) != Locales["de"] {
+		t.Errorf("Expected the de locale of the token")
+	}
+}
+
+func TestStatsText(t *testing.T) {
+	fixture, err := LoadFixture("testdata/preview_fixture.json")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	fixture.Pool.Name = "ANON <TON>"
+	fixture.Pool.H6.Buys, fixture.Pool.H6.Sells = 0, 0
+
+	expected := strings.Join([]string{
+		"<b>ANON &lt;TON&gt;</b>",
+		"<pre>Price      $0.00851",
+		"FDV        $8.5M",
+		"Liquidity  $412K",
+		"",
+		"       Change   Volume  Buys  Sells",
+		"   5m  +0.12%    $1.5K     4      2",
+		"   1h  -1.30%    $5.5K    11      9",
+		"   6h  +2.40%   $30.1K     -      -",
+		"  24h  +5.70%  $120.4K   240    233</pre>",
+	}, "\n")
+
+	if result := statsText(fixture.Pool, Locales["en"]); result != expected {
+		t.Errorf("Expected\n%s\nbut got\n%s", expected, result)
+	}
+
+	if result := priceSummary(fixture.Pool, Locales["ru"]); !strings.Contains(result, "$0,00851 +5,70% 24h") {
+		t.Errorf("Expected the ru price and change, but got %s", result)
+	}
+}
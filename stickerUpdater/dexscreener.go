@@ -0,0 +1,163 @@
+package stickerUpdater
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type DexscreenerTransactions struct {
+	Buys  int `json:"buys"`
+	Sells int `json:"sells"`
+}
+
+type DexscreenerToken struct {
+	Address string `json:"address"`
+	Name    string `json:"name"`
+	Symbol  string `json:"symbol"`
+}
+
+type DexscreenerPair struct {
+	ChainID     string           `json:"chainId"`
+	DexID       string           `json:"dexId"`
+	URL         string           `json:"url"`
+	PairAddress string           `json:"pairAddress"`
+	BaseToken   DexscreenerToken `json:"baseToken"`
+	QuoteToken  DexscreenerToken `json:"quoteToken"`
+	PriceNative string           `json:"priceNative"`
+	PriceUsd    string           `json:"priceUsd"`
+	Txns        struct {
+		M5  DexscreenerTransactions `json:"m5"`
+		H1  DexscreenerTransactions `json:"h1"`
+		H6  DexscreenerTransactions `json:"h6"`
+		H24 DexscreenerTransactions `json:"h24"`
+	} `json:"txns"`
+	Volume struct {
+		M5  float64 `json:"m5"`
+		H1  float64 `json:"h1"`
+		H6  float64 `json:"h6"`
+		H24 float64 `json:"h24"`
+	} `json:"volume"`
+	PriceChange struct {
+		M5  float64 `json:"m5"`
+		H1  float64 `json:"h1"`
+		H6  float64 `json:"h6"`
+		H24 float64 `json:"h24"`
+	} `json:"priceChange"`
+	Liquidity struct {
+		Usd   float64 `json:"usd"`
+		Base  float64 `json:"base"`
+		Quote float64 `json:"quote"`
+	} `json:"liquidity"`
+	Fdv           float64 `json:"fdv"`
+	MarketCap     float64 `json:"marketCap"`
+	PairCreatedAt int64   `json:"pairCreatedAt"`
+}
+
+type DexscreenerResponse struct {
+	SchemaVersion string            `json:"schemaVersion"`
+	Pairs         []DexscreenerPair `json:"pairs"`
+}
+
+// DexscreenerProvider reads pool data from the DexScreener pairs endpoint.
+// DexScreener has no public candle API, so candles come from a separate source.
+type DexscreenerProvider struct {
+	client  *http.Client
+	dataURL string
+	candles MarketDataProvider
+}
+
+func NewDexscreenerProvider(client *http.Client, dataURL string, candles MarketDataProvider) *DexscreenerProvider {
+	return &DexscreenerProvider{
+		client:  client,
+		dataURL: dataURL,
+		candles: candles,
+	}
+}
+
+func (p *DexscreenerProvider) Name() string {
+	return "dexscreener"
+}
+
+func (p *DexscreenerProvider) GetPool(ctx context.Context, address string) (PoolSnapshot, error) {
+	var data DexscreenerResponse
+
+	dataURL := strings.Replace(p.dataURL, "%s", address, 1)
+
+	if err := getJson(ctx, p.client, dataURL, &data); err != nil {
+		return PoolSnapshot{}, fmt.Errorf("%w (%s)", err, dataURL)
+	}
+
+	// another pool must never be shown under the token
+	var pair *DexscreenerPair
+	for i := range data.Pairs {
+		if strings.EqualFold(data.Pairs[i].PairAddress, address) {
+			pair = &data.Pairs[i]
+			break
+		}
+	}
+
+	if pair == nil {
+		return PoolSnapshot{}, fmt.Errorf("pair %s not found (%s)", address, dataURL)
+	}
+
+	priceNative := parseFloat(pair.PriceNative)
+	priceUsd := parseFloat(pair.PriceUsd)
+
+	snapshot := PoolSnapshot{
+		Name:    pair.BaseToken.Symbol + " / " + pair.QuoteToken.Symbol,
+		Address: pair.PairAddress,
+
+		PriceUSD:                 priceUsd,
+		BaseTokenPriceQuoteToken: priceNative,
+
+		FdvUSD:     pair.Fdv,
+		ReserveUSD: pair.Liquidity.Usd,
+
+		M5: Window{
+			Volume:      pair.Volume.M5,
+			Buys:        pair.Txns.M5.Buys,
+			Sells:       pair.Txns.M5.Sells,
+			PriceChange: pair.PriceChange.M5,
+		},
+		H1: Window{
+			Volume:      pair.Volume.H1,
+			Buys:        pair.Txns.H1.Buys,
+			Sells:       pair.Txns.H1.Sells,
+			PriceChange: pair.PriceChange.H1,
+		},
+		H6: Window{
+			Volume:      pair.Volume.H6,
+			Buys:        pair.Txns.H6.Buys,
+			Sells:       pair.Txns.H6.Sells,
+			PriceChange: pair.PriceChange.H6,
+		},
+		H24: Window{
+			Volume:      pair.Volume.H24,
+			Buys:        pair.Txns.H24.Buys,
+			Sells:       pair.Txns.H24.Sells,
+			PriceChange: pair.PriceChange.H24,
+		},
+	}
+
+	if priceNative != 0 {
+		snapshot.QuoteTokenPriceBaseToken = 1 / priceNative
+		snapshot.QuoteTokenPriceUSD = priceUsd / priceNative
+	}
+
+	return snapshot, nil
+}
+
+// CandleProvider is the provider of the candles.
+func (p *DexscreenerProvider) CandleProvider() MarketDataProvider {
+	return p.candles
+}
+
+func (p *DexscreenerProvider) GetCandles(ctx context.Context, address string, query CandleQuery) ([]Candle, error) {
+	if p.candles == nil {
+		return nil, nil
+	}
+
+	return p.candles.GetCandles(ctx, address, query)
+}
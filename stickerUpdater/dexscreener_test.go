@@ -0,0 +1,126 @@
+package stickerUpdater
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+const testPoolAddress = "EQAjeq_aW_fSP7XqoF15ZZ7zUYiWLqv6UccN-jJlliomy-B3"
+
+func newFixtureServer(t *testing.T, routes map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fixture, ok := routes[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		data, err := os.ReadFile(fixture)
+		if err != nil {
+			t.Errorf("read fixture %s: %v", fixture, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestDexscreenerProviderGetPool(t *testing.T) {
+	server := newFixtureServer(t, map[string]string{
+		"/latest/dex/pairs/ton/" + testPoolAddress: "testdata/dexscreener_pair.json",
+	})
+
+	provider := NewDexscreenerProvider(server.Client(), server.URL+"/latest/dex/pairs/ton/%s", nil)
+
+	snapshot, err := provider.GetPool(context.Background(), testPoolAddress)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if snapshot.Name != "ANON / TON" {
+		t.Errorf("Expected name 'ANON / TON', but got '%s'", snapshot.Name)
+	}
+	if snapshot.PriceUSD != 0.008512 {
+		t.Errorf("Expected PriceUSD 0.008512, but got %f", snapshot.PriceUSD)
+	}
+	if snapshot.QuoteTokenPriceBaseToken != 625 {
+		t.Errorf("Expected QuoteTokenPriceBaseToken 625, but got %f", snapshot.QuoteTokenPriceBaseToken)
+	}
+	if snapshot.FdvUSD != 8512000 {
+		t.Errorf("Expected FdvUSD 8512000, but got %f", snapshot.FdvUSD)
+	}
+	if snapshot.M5.Volume != 312.4 || snapshot.M5.Buys != 3 || snapshot.M5.Sells != 1 {
+		t.Errorf("Unexpected M5 window %+v", snapshot.M5)
+	}
+	if snapshot.H1.PriceChange != -1.45 {
+		t.Errorf("Expected H1 price change -1.45, but got %f", snapshot.H1.PriceChange)
+	}
+	if snapshot.H24.Buys != 1042 || snapshot.H24.Sells != 987 {
+		t.Errorf("Unexpected H24 window %+v", snapshot.H24)
+	}
+}
+
+func TestDexscreenerProviderGetPoolNotFound(t *testing.T) {
+	server := newFixtureServer(t, map[string]string{})
+
+	provider := NewDexscreenerProvider(server.Client(), server.URL+"/latest/dex/pairs/ton/%s", nil)
+
+	if _, err := provider.GetPool(context.Background(), testPoolAddress); err == nil {
+		t.Errorf("Expected error for unknown pair, but got nil")
+	}
+}
+
+func TestDexscreenerProviderGetCandles(t *testing.T) {
+	server := newFixtureServer(t, map[string]string{
+		"/pools/" + testPoolAddress + "/ohlcv/minute": "testdata/geckoterminal_ohlcv.json",
+	})
+
+	candles := NewGeckoterminalProvider(server.Client(), "", server.URL+"/pools/%s/ohlcv")
+	provider := NewDexscreenerProvider(server.Client(), server.URL+"/latest/dex/pairs/ton/%s", candles)
+
+	result, err := provider.GetCandles(context.Background(), testPoolAddress, CandleQuery{Timeframe: "15m", Limit: 3})
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if len(result) != 3 {
+		t.Fatalf("Expected 3 candles, but got %d", len(result))
+	}
+	if !result[0].Time.Before(result[2].Time) {
+		t.Errorf("Expected candles ordered oldest first, but got %v before %v", result[0].Time, result[2].Time)
+	}
+	if result[2].Close != 0.00851 {
+		t.Errorf("Expected last close 0.00851, but got %f", result[2].Close)
+	}
+}
+
+func TestDexscreenerProviderGetPoolOtherPair(t *testing.T) {
+	lowercase := strings.ToLower(testPoolAddress)
+
+	server := newFixtureServer(t, map[string]string{
+		"/latest/dex/pairs/ton/EQother":      "testdata/dexscreener_pair.json",
+		"/latest/dex/pairs/ton/" + lowercase: "testdata/dexscreener_pair.json",
+	})
+
+	provider := NewDexscreenerProvider(server.Client(), server.URL+"/latest/dex/pairs/ton/%s", nil)
+
+	if _, err := provider.GetPool(context.Background(), "EQother"); err == nil {
+		t.Errorf("Expected error for a response without the pair, but got nil")
+	}
+
+	if _, err := provider.GetPool(context.Background(), lowercase); err != nil {
+		t.Errorf("Expected the pair matched in any case, but got %v", err)
+	}
+}
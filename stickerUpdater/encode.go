@@ -0,0 +1,215 @@
+package stickerUpdater
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nickalie/go-binwrapper"
+	"github.com/nickalie/go-webpbin"
+)
+
+const (
+	EncoderCWebP  = "cwebp"  // lossy, the cwebp binary
+	EncoderNative = "native" // lossless, in process
+
+	// staticStickerMaxSize and videoStickerMaxSize are the Telegram limits.
+	staticStickerMaxSize = 512 * 1024
+	videoStickerMaxSize  = 256 * 1024
+)
+
+var (
+	// webpQuality are the cwebp qualities tried in order until the sticker
+	// fits staticStickerMaxSize.
+	webpQuality = []int{75, 60, 45, 30, 15}
+	// losslessQuantization are the low bits of each channel dropped by the
+	// native encoder in order. With 7 bits dropped a 512x512 sticker always
+	// fits, so it's the last resort of both encoders.
+	losslessQuantization = []int{0, 2, 3, 4, 5, 6, 7}
+	// videoCRF are the VP9 quality levels tried in order until the video fits
+	// videoStickerMaxSize, lower is better.
+	videoCRF = []int{30, 40, 50, 63}
+)
+
+// encodeWebP encodes img with encoder, lowering the quality until the sticker
+// fits the Telegram size limit.
+func encodeWebP(ctx context.Context, encoder string, img image.Image) ([]byte, error) {
+	var attempts []func() ([]byte, error)
+
+	switch encoder {
+	case EncoderCWebP, "":
+		for _, quality := range webpQuality {
+			attempts = append(attempts, func() ([]byte, error) { return encodeCWebP(ctx, img, quality) })
+		}
+
+		lastResort := losslessQuantization[len(losslessQuantization)-1]
+		attempts = append(attempts, func() ([]byte, error) { return encodeLossless(img, lastResort) })
+	case EncoderNative:
+		for _, quantize := range losslessQuantization {
+			attempts = append(attempts, func() ([]byte, error) { return encodeLossless(img, quantize) })
+		}
+	default:
+		return nil, fmt.Errorf("unknown webp encoder %q", encoder)
+	}
+
+	size := 0
+
+	for _, attempt := range attempts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		data, err := attempt()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(data) <= staticStickerMaxSize {
+			return data, nil
+		}
+
+		size = len(data)
+	}
+
+	return nil, fmt.Errorf("sticker is %d bytes, over the %d bytes limit", size, staticStickerMaxSize)
+}
+
+// encodeCWebP encodes img with the cwebp binary. The binary can't take a
+// context, so the process gets killed once the context deadline passes.
+func encodeCWebP(ctx context.Context, img image.Image, quality int) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	cwebp := webpbin.NewCWebP().Quality(uint(quality)).InputImage(img).Output(buf)
+
+	timeout, err := binTimeout(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if timeout > 0 {
+		cwebp.Timeout(timeout)
+	}
+
+	if err := cwebp.Run(); err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// encodeWebM encodes frames into a VP9 WebM video sticker with the ffmpeg
+// binary, lowering the quality until the video fits the Telegram size limit.
+// Like cwebp, ffmpeg is taken from VENDOR_PATH when it's there, otherwise
+// from PATH, and is never downloaded.
+func encodeWebM(ctx context.Context, ffmpegPath string, frames []image.Image, fps int) ([]byte, error) {
+	input := new(bytes.Buffer)
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+
+	for _, frame := range frames {
+		if err := encoder.Encode(input, frame); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, crf := range videoCRF {
+		timeout, err := binTimeout(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		video, err := runFFmpeg(ffmpegPath, input.Bytes(), fps, crf, timeout)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(video) <= videoStickerMaxSize {
+			return video, nil
+		}
+	}
+
+	return nil, fmt.Errorf("video is over %d bytes even with crf %d", videoStickerMaxSize, videoCRF[len(videoCRF)-1])
+}
+
+func runFFmpeg(ffmpegPath string, input []byte, fps, crf int, timeout time.Duration) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	ffmpeg := newBinWrapper(ffmpegPath).
+		StdIn(bytes.NewReader(input)).
+		SetStdOut(buf).
+		Arg("-hide_banner").
+		Arg("-loglevel", "error").
+		Arg("-f", "image2pipe").
+		Arg("-framerate", strconv.Itoa(fps)).
+		Arg("-i", "pipe:0").
+		Arg("-c:v", "libvpx-vp9").
+		Arg("-pix_fmt", "yuva420p").
+		Arg("-b:v", "0").
+		Arg("-crf", strconv.Itoa(crf)).
+		Arg("-an").
+		Arg("-f", "webm").
+		Arg("pipe:1")
+
+	if timeout > 0 {
+		ffmpeg.Timeout(timeout)
+	}
+
+	if err := ffmpeg.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg: %w %s", err, strings.TrimSpace(string(ffmpeg.StdErr())))
+	}
+
+	return buf.Bytes(), nil
+}
+
+// newBinWrapper runs the binary at execPath, from VENDOR_PATH when it's there.
+func newBinWrapper(execPath string) *binwrapper.BinWrapper {
+	bin := binwrapper.NewBinWrapper().ExecPath(execPath)
+
+	if path := os.Getenv("VENDOR_PATH"); path != "" && !filepath.IsAbs(execPath) {
+		if _, err := os.Stat(filepath.Join(path, execPath)); err == nil {
+			bin.Dest(path)
+		}
+	}
+
+	return bin
+}
+
+// binAvailable tells whether the binary at execPath is found the way
+// newBinWrapper runs it: in VENDOR_PATH or in PATH.
+func binAvailable(execPath string) bool {
+	if path := os.Getenv("VENDOR_PATH"); path != "" && !filepath.IsAbs(execPath) {
+		if _, err := os.Stat(filepath.Join(path, execPath)); err == nil {
+			return true
+		}
+	}
+
+	_, err := exec.LookPath(execPath)
+
+	return err == nil
+}
+
+// binTimeout returns the time left until the ctx deadline, 0 if there is none.
+func binTimeout(ctx context.Context) (time.Duration, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, nil
+	}
+
+	timeout := time.Until(deadline)
+	if timeout <= 0 {
+		return 0, context.DeadlineExceeded
+	}
+
+	return timeout, nil
+}
@@ -0,0 +1,292 @@
+package stickerUpdater
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	defaultFont    = "goregular"
+	fontsDirName   = "fonts"
+	fontsDPI       = 72
+	fontExtensions = ".ttf .otf .ttc .otc"
+)
+
+var builtinFonts = map[string][]byte{
+	"goregular": goregular.TTF,
+	"gobold":    gobold.TTF,
+}
+
+type faceKey struct {
+	path string
+	size float64
+}
+
+// FontCache keeps parsed fonts and their faces between updates. A face is not
+// safe for concurrent use, so every render takes faces from a pool and puts
+// them back once it's done.
+type FontCache struct {
+	sync.Mutex
+	sharedDir string
+	fonts     map[string]*sfnt.Font
+	faces     map[faceKey]*sync.Pool
+}
+
+func NewFontCache(sharedDir string) *FontCache {
+	return &FontCache{
+		sharedDir: sharedDir,
+		fonts:     make(map[string]*sfnt.Font),
+		faces:     make(map[faceKey]*sync.Pool),
+	}
+}
+
+// resolve finds a font by name in the token fonts directory, then in the
+// shared fonts directory, then among the built-in fonts. The name is the
+// file name without extension, e.g. "NotoSans-Bold".
+func (fc *FontCache) resolve(tokenDir, name string) (string, error) {
+	var dirs []string
+	if tokenDir != "" {
+		dirs = append(dirs, filepath.Join(tokenDir, fontsDirName))
+	}
+	if fc.sharedDir != "" {
+		dirs = append(dirs, fc.sharedDir)
+	}
+
+	for _, dir := range dirs {
+		for _, ext := range strings.Fields(fontExtensions) {
+			path := filepath.Join(dir, name+ext)
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			}
+		}
+	}
+
+	if _, ok := builtinFonts[strings.ToLower(name)]; ok {
+		return strings.ToLower(name), nil
+	}
+
+	return "", fmt.Errorf("font %q not found", name)
+}
+
+func (fc *FontCache) font(path string) (*sfnt.Font, error) {
+	fc.Lock()
+	defer fc.Unlock()
+
+	if f, ok := fc.fonts[path]; ok {
+		return f, nil
+	}
+
+	data, ok := builtinFonts[path]
+	if !ok {
+		var err error
+
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	collection, err := opentype.ParseCollection(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	f, err := collection.Font(0)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	fc.fonts[path] = f
+
+	return f, nil
+}
+
+func (fc *FontCache) pool(key faceKey, f *sfnt.Font) *sync.Pool {
+	fc.Lock()
+	defer fc.Unlock()
+
+	pool, ok := fc.faces[key]
+	if !ok {
+		pool = &sync.Pool{
+			New: func() any {
+				face, err := opentype.NewFace(f, &opentype.FaceOptions{
+					Size:    key.size,
+					DPI:     fontsDPI,
+					Hinting: font.HintingNone,
+				})
+				if err != nil {
+					return nil
+				}
+
+				return face
+			},
+		}
+		fc.faces[key] = pool
+	}
+
+	return pool
+}
+
+func (fc *FontCache) put(key faceKey, face font.Face) {
+	fc.Lock()
+	defer fc.Unlock()
+
+	// the cache was purged while the face was in use
+	if pool, ok := fc.faces[key]; ok {
+		pool.Put(face)
+	}
+}
+
+// Purge forgets parsed fonts, the tokens reload calls it when font files in
+// FONTS_PATH or the fonts of a token change.
+func (fc *FontCache) Purge() {
+	fc.Lock()
+	defer fc.Unlock()
+
+	fc.fonts = make(map[string]*sfnt.Font)
+	fc.faces = make(map[faceKey]*sync.Pool)
+}
+
+// fontSession hands out faces for a single render and returns them to the
+// cache on release.
+type fontSession struct {
+	cache    *FontCache
+	tokenDir string
+	taken    map[faceKey]font.Face
+	chains   map[string]font.Face
+}
+
+func (fc *FontCache) session(tokenDir string) *fontSession {
+	return &fontSession{
+		cache:    fc,
+		tokenDir: tokenDir,
+		taken:    make(map[faceKey]font.Face),
+		chains:   make(map[string]font.Face),
+	}
+}
+
+func (fs *fontSession) face(name string, size float64) (font.Face, error) {
+	path, err := fs.cache.resolve(fs.tokenDir, name)
+	if err != nil {
+		return nil, err
+	}
+
+	key := faceKey{path: path, size: size}
+	if face, ok := fs.taken[key]; ok {
+		return face, nil
+	}
+
+	f, err := fs.cache.font(path)
+	if err != nil {
+		return nil, err
+	}
+
+	face, ok := fs.cache.pool(key, f).Get().(font.Face)
+	if !ok || face == nil {
+		return nil, fmt.Errorf("font %q: can't create face of size %.1f", name, size)
+	}
+
+	fs.taken[key] = face
+
+	return face, nil
+}
+
+// chain returns a face that draws each rune with the first font in names
+// that has a glyph for it, the built-in font is always the last resort.
+func (fs *fontSession) chain(names []string, size float64) (font.Face, error) {
+	names = append(slices.Clone(names), defaultFont)
+
+	chainKey := fmt.Sprintf("%s@%.2f", strings.Join(names, ","), size)
+	if face, ok := fs.chains[chainKey]; ok {
+		return face, nil
+	}
+
+	var faces []font.Face
+
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+
+		face, err := fs.face(name, size)
+		if err != nil {
+			return nil, err
+		}
+
+		faces = append(faces, face)
+	}
+
+	var face font.Face = faces[0]
+	if len(faces) > 1 {
+		face = &fallbackFace{faces: faces}
+	}
+
+	fs.chains[chainKey] = face
+
+	return face, nil
+}
+
+func (fs *fontSession) release() {
+	for key, face := range fs.taken {
+		fs.cache.put(key, face)
+	}
+
+	fs.taken = nil
+	fs.chains = nil
+}
+
+// fallbackFace implements font.Face over a list of faces.
+type fallbackFace struct {
+	faces []font.Face
+}
+
+func (ff *fallbackFace) faceFor(r rune) font.Face {
+	for _, face := range ff.faces {
+		if _, ok := face.GlyphAdvance(r); ok {
+			return face
+		}
+	}
+
+	return ff.faces[0]
+}
+
+func (ff *fallbackFace) Close() error {
+	return nil
+}
+
+func (ff *fallbackFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	return ff.faceFor(r).Glyph(dot, r)
+}
+
+func (ff *fallbackFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	return ff.faceFor(r).GlyphBounds(r)
+}
+
+func (ff *fallbackFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	return ff.faceFor(r).GlyphAdvance(r)
+}
+
+func (ff *fallbackFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	face := ff.faceFor(r0)
+	if face != ff.faceFor(r1) {
+		return 0
+	}
+
+	return face.Kern(r0, r1)
+}
+
+func (ff *fallbackFace) Metrics() font.Metrics {
+	return ff.faces[0].Metrics()
+}
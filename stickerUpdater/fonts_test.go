@@ -0,0 +1,78 @@
+package stickerUpdater
+
+import (
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+)
+
+func TestFontSessionChain(t *testing.T) {
+	cache := NewFontCache("")
+
+	fonts := cache.session("")
+
+	face, err := fonts.chain([]string{"gobold"}, 24)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if _, ok := face.(*fallbackFace); !ok {
+		t.Errorf("Expected fallback face for a chain of two fonts, but got %T", face)
+	}
+
+	if faces := face.(*fallbackFace).faces; len(faces) != 2 || faces[1] != fonts.taken[faceKey{path: defaultFont, size: 24}] {
+		t.Errorf("Expected gobold then the built-in font, but got %v", faces)
+	}
+
+	same, _ := fonts.chain([]string{"gobold"}, 24)
+	if same != face {
+		t.Errorf("Expected the same face within a session")
+	}
+
+	fonts.release()
+
+	if len(cache.fonts) != 2 {
+		t.Errorf("Expected 2 parsed fonts in the cache, but got %d", len(cache.fonts))
+	}
+
+	if _, err := cache.session("").chain([]string{"NoSuchFont"}, 24); err == nil {
+		t.Errorf("Expected error for unknown font, but got nil")
+	}
+}
+
+func TestFallbackFace(t *testing.T) {
+	fonts := NewFontCache("").session("")
+	defer fonts.release()
+
+	regular, err := fonts.face(defaultFont, 13)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	// ASCII only, so Cyrillic comes from the fallback
+	primary := basicfont.Face7x13
+	face := &fallbackFace{faces: []font.Face{primary, regular}}
+
+	if face.faceFor('A') != primary {
+		t.Errorf("Expected the primary face to draw A")
+	}
+
+	if face.faceFor('Ж') != regular {
+		t.Errorf("Expected the fallback face to draw Ж")
+	}
+
+	expected, _ := regular.GlyphAdvance('Ж')
+	if advance, ok := face.GlyphAdvance('Ж'); !ok || advance != expected {
+		t.Errorf("Expected the advance %v of the fallback face, but got %v", expected, advance)
+	}
+
+	// in no face at all, the primary draws its replacement glyph
+	if face.faceFor('中') != primary {
+		t.Errorf("Expected the primary face for a rune missing everywhere")
+	}
+
+	if face.Kern('A', 'Ж') != 0 {
+		t.Errorf("Expected no kerning between faces")
+	}
+}
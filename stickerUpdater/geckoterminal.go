@@ -0,0 +1,296 @@
+package stickerUpdater
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type GeckoterminalResponse struct {
+	Data struct {
+		ID         string `json:"id"`
+		Type       string `json:"type"`
+		Attributes struct {
+			BaseTokenPriceUsd             string      `json:"base_token_price_usd"`
+			BaseTokenPriceNativeCurrency  string      `json:"base_token_price_native_currency"`
+			QuoteTokenPriceUsd            string      `json:"quote_token_price_usd"`
+			QuoteTokenPriceNativeCurrency string      `json:"quote_token_price_native_currency"`
+			BaseTokenPriceQuoteToken      string      `json:"base_token_price_quote_token"`
+			QuoteTokenPriceBaseToken      string      `json:"quote_token_price_base_token"`
+			Address                       string      `json:"address"`
+			Name                          string      `json:"name"`
+			PoolCreatedAt                 time.Time   `json:"pool_created_at"`
+			FdvUsd                        string      `json:"fdv_usd"`
+			MarketCapUsd                  interface{} `json:"market_cap_usd"`
+			PriceChangePercentage         struct {
+				M5  string `json:"m5"`
+				H1  string `json:"h1"`
+				H6  string `json:"h6"`
+				H24 string `json:"h24"`
+			} `json:"price_change_percentage"`
+			Transactions struct {
+				M5 struct {
+					Buys    int `json:"buys"`
+					Sells   int `json:"sells"`
+					Buyers  int `json:"buyers"`
+					Sellers int `json:"sellers"`
+				} `json:"m5"`
+				M15 struct {
+					Buys    int `json:"buys"`
+					Sells   int `json:"sells"`
+					Buyers  int `json:"buyers"`
+					Sellers int `json:"sellers"`
+				} `json:"m15"`
+				M30 struct {
+					Buys    int `json:"buys"`
+					Sells   int `json:"sells"`
+					Buyers  int `json:"buyers"`
+					Sellers int `json:"sellers"`
+				} `json:"m30"`
+				H1 struct {
+					Buys    int `json:"buys"`
+					Sells   int `json:"sells"`
+					Buyers  int `json:"buyers"`
+					Sellers int `json:"sellers"`
+				} `json:"h1"`
+				H24 struct {
+					Buys    int `json:"buys"`
+					Sells   int `json:"sells"`
+					Buyers  int `json:"buyers"`
+					Sellers int `json:"sellers"`
+				} `json:"h24"`
+			} `json:"transactions"`
+			VolumeUsd struct {
+				M5  string `json:"m5"`
+				H1  string `json:"h1"`
+				H6  string `json:"h6"`
+				H24 string `json:"h24"`
+			} `json:"volume_usd"`
+			ReserveInUsd string `json:"reserve_in_usd"`
+		} `json:"attributes"`
+		Relationships struct {
+			BaseToken struct {
+				Data struct {
+					ID   string `json:"id"`
+					Type string `json:"type"`
+				} `json:"data"`
+			} `json:"base_token"`
+			QuoteToken struct {
+				Data struct {
+					ID   string `json:"id"`
+					Type string `json:"type"`
+				} `json:"data"`
+			} `json:"quote_token"`
+			Dex struct {
+				Data struct {
+					ID   string `json:"id"`
+					Type string `json:"type"`
+				} `json:"data"`
+			} `json:"dex"`
+		} `json:"relationships"`
+	} `json:"data"`
+}
+
+type GeckoterminalOHLCVResponse struct {
+	Data struct {
+		ID         string `json:"id"`
+		Type       string `json:"type"`
+		Attributes struct {
+			OhlcvList [][]float64 `json:"ohlcv_list"`
+		} `json:"attributes"`
+	} `json:"data"`
+	Meta struct {
+		Base struct {
+			Address         string `json:"address"`
+			Name            string `json:"name"`
+			Symbol          string `json:"symbol"`
+			CoingeckoCoinID any    `json:"coingecko_coin_id"`
+		} `json:"base"`
+		Quote struct {
+			Address         string `json:"address"`
+			Name            string `json:"name"`
+			Symbol          string `json:"symbol"`
+			CoingeckoCoinID any    `json:"coingecko_coin_id"`
+		} `json:"quote"`
+	} `json:"meta"`
+}
+
+// GeckoterminalProvider reads pool data and candles from the GeckoTerminal API.
+type GeckoterminalProvider struct {
+	client       *http.Client
+	dataURL      string
+	dataOhlcvURL string
+}
+
+func NewGeckoterminalProvider(client *http.Client, dataURL, dataOhlcvURL string) *GeckoterminalProvider {
+	return &GeckoterminalProvider{
+		client:       client,
+		dataURL:      dataURL,
+		dataOhlcvURL: dataOhlcvURL,
+	}
+}
+
+func (p *GeckoterminalProvider) Name() string {
+	return "geckoterminal"
+}
+
+func (p *GeckoterminalProvider) GetPool(ctx context.Context, address string) (PoolSnapshot, error) {
+	var data GeckoterminalResponse
+
+	dataURL := strings.Replace(p.dataURL, "%s", address, 1)
+
+	if err := getJson(ctx, p.client, dataURL, &data); err != nil {
+		return PoolSnapshot{}, fmt.Errorf("%w (%s)", err, dataURL)
+	}
+
+	attributes := data.Data.Attributes
+
+	return PoolSnapshot{
+		Name:    attributes.Name,
+		Address: attributes.Address,
+
+		PriceUSD:                 parseFloat(attributes.BaseTokenPriceUsd),
+		QuoteTokenPriceUSD:       parseFloat(attributes.QuoteTokenPriceUsd),
+		BaseTokenPriceQuoteToken: parseFloat(attributes.BaseTokenPriceQuoteToken),
+		QuoteTokenPriceBaseToken: parseFloat(attributes.QuoteTokenPriceBaseToken),
+
+		FdvUSD:     parseFloat(attributes.FdvUsd),
+		ReserveUSD: parseFloat(attributes.ReserveInUsd),
+
+		M5: Window{
+			Volume:      parseFloat(attributes.VolumeUsd.M5),
+			Buys:        attributes.Transactions.M5.Buys,
+			Sells:       attributes.Transactions.M5.Sells,
+			PriceChange: parseFloat(attributes.PriceChangePercentage.M5),
+		},
+		H1: Window{
+			Volume:      parseFloat(attributes.VolumeUsd.H1),
+			Buys:        attributes.Transactions.H1.Buys,
+			Sells:       attributes.Transactions.H1.Sells,
+			PriceChange: parseFloat(attributes.PriceChangePercentage.H1),
+		},
+		H6: Window{
+			Volume:      parseFloat(attributes.VolumeUsd.H6),
+			PriceChange: parseFloat(attributes.PriceChangePercentage.H6),
+		},
+		H24: Window{
+			Volume:      parseFloat(attributes.VolumeUsd.H24),
+			Buys:        attributes.Transactions.H24.Buys,
+			Sells:       attributes.Transactions.H24.Sells,
+			PriceChange: parseFloat(attributes.PriceChangePercentage.H24),
+		},
+	}, nil
+}
+
+func (p *GeckoterminalProvider) GetCandles(ctx context.Context, address string, query CandleQuery) ([]Candle, error) {
+	if p.dataOhlcvURL == "" {
+		return nil, nil
+	}
+
+	var data GeckoterminalOHLCVResponse
+
+	dataOhlcvURL, err := geckoterminalOHLCVURL(p.dataOhlcvURL, address, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := getJson(ctx, p.client, dataOhlcvURL, &data); err != nil {
+		return nil, fmt.Errorf("%w (%s)", err, dataOhlcvURL)
+	}
+
+	var ohlcvData []Candle
+
+	for _, ohlcv := range data.Data.Attributes.OhlcvList {
+		if len(ohlcv) < 6 {
+			continue
+		}
+
+		ohlcvData = append(ohlcvData, Candle{
+			Time:   time.Unix(int64(ohlcv[0]), 0),
+			Open:   ohlcv[1],
+			High:   ohlcv[2],
+			Low:    ohlcv[3],
+			Close:  ohlcv[4],
+			Volume: ohlcv[5],
+		})
+	}
+
+	slices.Reverse(ohlcvData)
+
+	return ohlcvData, nil
+}
+
+// geckoterminalOHLCVURL builds the OHLCV URL for query from DATA_OHLCV_URL,
+// anything after "/ohlcv" in it is replaced.
+func geckoterminalOHLCVURL(dataOhlcvURL, address string, query CandleQuery) (string, error) {
+	duration, ok := Timeframes[query.Timeframe]
+	if !ok {
+		return "", fmt.Errorf("unknown timeframe %q", query.Timeframe)
+	}
+
+	period, aggregate := "minute", int(duration/time.Minute)
+	switch {
+	case duration >= 24*time.Hour:
+		period, aggregate = "day", int(duration/(24*time.Hour))
+	case duration >= time.Hour:
+		period, aggregate = "hour", int(duration/time.Hour)
+	}
+
+	base, _, _ := strings.Cut(dataOhlcvURL, "/ohlcv")
+	base = strings.Replace(base, "%s", address, 1)
+
+	return fmt.Sprintf("%s/ohlcv/%s?aggregate=%d&limit=%d&currency=usd", base, period, aggregate, query.Limit), nil
+}
+
+func parseFloat(value string) float64 {
+	result, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+
+	return result
+}
+
+type GeckoterminalPoolsResponse struct {
+	Data []struct {
+		Attributes struct {
+			Address string `json:"address"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+// FindPool returns the first of the token pools, GeckoTerminal lists the most
+// traded ones first.
+func (p *GeckoterminalProvider) FindPool(ctx context.Context, tokenAddress string) (string, error) {
+	poolsURL, err := geckoterminalTokenPoolsURL(p.dataURL, tokenAddress)
+	if err != nil {
+		return "", err
+	}
+
+	var data GeckoterminalPoolsResponse
+
+	if err := getJson(ctx, p.client, poolsURL, &data); err != nil {
+		return "", fmt.Errorf("%w (%s)", err, poolsURL)
+	}
+
+	if len(data.Data) == 0 || data.Data[0].Attributes.Address == "" {
+		return "", fmt.Errorf("no pools of %s (%s)", tokenAddress, poolsURL)
+	}
+
+	return data.Data[0].Attributes.Address, nil
+}
+
+// geckoterminalTokenPoolsURL builds the URL of the token pools from DATA_URL,
+// ".../networks/ton/pools/%s?..." becomes ".../networks/ton/tokens/<address>/pools".
+func geckoterminalTokenPoolsURL(dataURL, tokenAddress string) (string, error) {
+	base, _, ok := strings.Cut(dataURL, "/pools/")
+	if !ok {
+		return "", fmt.Errorf("no /pools/ in DATA_URL %s", dataURL)
+	}
+
+	return fmt.Sprintf("%s/tokens/%s/pools", base, tokenAddress), nil
+}
@@ -0,0 +1,47 @@
+package stickerUpdater
+
+import "testing"
+
+func TestGeckoterminalOHLCVURL(t *testing.T) {
+	tests := []struct {
+		base  string
+		query CandleQuery
+		want  string
+	}{
+		{"https://api/pools/%s/ohlcv", CandleQuery{Timeframe: "15m", Limit: 24}, "https://api/pools/addr/ohlcv/minute?aggregate=15&limit=24&currency=usd"},
+		{"https://api/pools/%s/ohlcv", CandleQuery{Timeframe: "4h", Limit: 42}, "https://api/pools/addr/ohlcv/hour?aggregate=4&limit=42&currency=usd"},
+		{"https://api/pools/%s/ohlcv", CandleQuery{Timeframe: "1d", Limit: 7}, "https://api/pools/addr/ohlcv/day?aggregate=1&limit=7&currency=usd"},
+		// the old format with the query baked in
+		{"https://api/pools/%s/ohlcv/minute?aggregate=15&limit=24&currency=usd", CandleQuery{Timeframe: "1h", Limit: 6}, "https://api/pools/addr/ohlcv/hour?aggregate=1&limit=6&currency=usd"},
+	}
+
+	for _, test := range tests {
+		got, err := geckoterminalOHLCVURL(test.base, "addr", test.query)
+		if err != nil {
+			t.Fatalf("Expected no error, but got %v", err)
+		}
+
+		if got != test.want {
+			t.Errorf("Expected %s, but got %s", test.want, got)
+		}
+	}
+
+	if _, err := geckoterminalOHLCVURL("https://api/pools/%s/ohlcv", "addr", CandleQuery{Timeframe: "2h", Limit: 6}); err == nil {
+		t.Errorf("Expected an error for an unknown timeframe")
+	}
+}
+
+func TestGeckoterminalTokenPoolsURL(t *testing.T) {
+	got, err := geckoterminalTokenPoolsURL("https://api/networks/ton/pools/%s?include=dex&base_token=0", "jetton")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if want := "https://api/networks/ton/tokens/jetton/pools"; got != want {
+		t.Errorf("Expected %s, but got %s", want, got)
+	}
+
+	if _, err := geckoterminalTokenPoolsURL("https://api/pairs/%s", "jetton"); err == nil {
+		t.Errorf("Expected an error for a URL without pools")
+	}
+}
@@ -0,0 +1,256 @@
+package stickerUpdater
+
+import (
+	"bytes"
+	"cmp"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/image/webp"
+)
+
+var update = flag.Bool("update", false, "regenerate the golden images in testdata/golden")
+
+const (
+	// goldenChannelTolerance is the channel difference not counted as a change,
+	// font rasterization and scaling may differ slightly between platforms.
+	goldenChannelTolerance = 8
+	// goldenPixelTolerance is the share of changed pixels a render may have.
+	goldenPixelTolerance = 0.001
+)
+
+// goldenTime is the clock of golden renders.
+var goldenTime = time.Date(2024, time.April, 6, 10, 30, 0, 0, time.UTC)
+
+const goldenAxesLayout = `{
+	"blocks": [
+		{"text": "{{.Name}}", "x": 24, "y": 48, "font_size": 32},
+		{"text": "${{price .PriceUSD}}", "x": 488, "y": 48, "anchor_x": 1, "font_size": 32, "color": "{{signColor .H24.PriceChange}}"},
+		{"text": "{{date .Now}}  FDV ${{compact .FdvUSD}}", "x": 24, "y": 80, "font_size": 18}
+	],
+	"charts": [
+		{"x": 16, "y": 112, "width": 480, "height": 384, "candle_width": 8, "columns": 24,
+		 "grid": true, "price_labels": true, "time_labels": true, "last_price": true}
+	]
+}`
+
+func TestGoldenRenders(t *testing.T) {
+	fixture, err := LoadFixture("testdata/preview_fixture.json")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	candles := goldenCandles(48)
+
+	tests := []struct {
+		name      string
+		template  string // sticker template, generated from the theme when empty
+		layout    string // the default layout when empty
+		theme     string
+		configure func(*StickerConfig)
+	}{
+		{name: "candles_dark", template: "../tokens/anon/sticker.webp", theme: "dark"},
+		{name: "line_light", theme: "light", configure: func(sc *StickerConfig) { sc.ChartType = ChartLine }},
+		{name: "area_high_contrast", theme: "high-contrast", configure: func(sc *StickerConfig) { sc.ChartType = ChartArea }},
+		{name: "ohlc_colorblind_indicators", theme: "colorblind", configure: func(sc *StickerConfig) {
+			sc.ChartType = ChartOHLC
+			sc.Volume = &VolumePanel{}
+			sc.Overlays = []Overlay{{Type: OverlaySMA, Period: 10}, {Type: OverlayBollinger, Period: 10}}
+		}},
+		{name: "candles_ru", theme: "dark", configure: func(sc *StickerConfig) { sc.Locale = "ru" }},
+		{name: "axes_ru_moscow", layout: goldenAxesLayout, theme: "dark", configure: func(sc *StickerConfig) {
+			sc.Locale, sc.Timezone = "ru", "Europe/Moscow"
+		}},
+		{name: "heikin_ashi_axes", layout: goldenAxesLayout, theme: "dark", configure: func(sc *StickerConfig) { sc.ChartType = ChartHeikinAshi }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			layout, err := parseLayout(defaultLayoutJSON)
+			if test.layout != "" {
+				layout, err = parseLayout([]byte(test.layout))
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, but got %v", err)
+			}
+
+			stickerConfig := &StickerConfig{Name: "Anon", layout: layout, theme: Themes[test.theme]}
+			if test.configure != nil {
+				test.configure(stickerConfig)
+			}
+
+			if err := stickerConfig.initChart(); err != nil {
+				t.Fatalf("Expected no error, but got %v", err)
+			}
+
+			stickerConfig.locale = Locales[cmp.Or(stickerConfig.Locale, DefaultLocale)]
+			stickerConfig.location, err = time.LoadLocation(stickerConfig.Timezone)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %v", err)
+			}
+
+			stickerConfig.image = generateBackground(stickerConfig.theme, nil)
+			if test.template != "" {
+				stickerConfig.image = goldenTemplate(t, test.template)
+			}
+
+			su := &StickerUpdater{fonts: NewFontCache(""), now: func() time.Time { return goldenTime }}
+
+			img, err := su.render(stickerConfig, fixture.Pool, candles)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %v", err)
+			}
+
+			compareGolden(t, filepath.Join("testdata", "golden", test.name+".png"), img)
+		})
+	}
+}
+
+// goldenCandles is a deterministic series with rises, falls, unchanged
+// candles and varying volume.
+func goldenCandles(count int) []Candle {
+	candles := make([]Candle, count)
+	price := 0.0085
+
+	for i := range candles {
+		change := float64((i*37)%11-5) * 0.00004
+		if i%9 == 0 {
+			change = 0
+		}
+
+		open := price
+		price += change
+
+		candles[i] = Candle{
+			Time:   goldenTime.Add(time.Duration(i-count) * 15 * time.Minute),
+			Open:   open,
+			High:   max(open, price) + float64(i%3)*0.00002,
+			Low:    min(open, price) - float64(i%4)*0.00002,
+			Close:  price,
+			Volume: float64(500 + (i*173)%2000),
+		}
+	}
+
+	return candles
+}
+
+func goldenTemplate(t *testing.T, path string) image.Image {
+	t.Helper()
+
+	file, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	img, err := webp.Decode(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	return img
+}
+
+// compareGolden compares img to the golden image at path, or writes it there
+// with -update. A diff image is written next to the test binary on failure.
+func compareGolden(t *testing.T, path string, img image.Image) {
+	t.Helper()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := writePNG(path, img); err != nil {
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	file, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%s: %v, run go test -update to create it", path, err)
+	}
+
+	golden, err := png.Decode(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+
+	if golden.Bounds() != img.Bounds() {
+		t.Fatalf("Expected %v image, but got %v", golden.Bounds(), img.Bounds())
+	}
+
+	diff, changed := diffImages(golden, img)
+
+	total := img.Bounds().Dx() * img.Bounds().Dy()
+	if float64(changed) <= goldenPixelTolerance*float64(total) {
+		return
+	}
+
+	diffPath := filepath.Join(os.TempDir(), filepath.Base(path[:len(path)-len(filepath.Ext(path))])+".diff.png")
+	if err := writePNG(diffPath, diff); err != nil {
+		t.Logf("diff image: %v", err)
+	}
+
+	t.Errorf("%s: %d of %d pixels differ, diff in %s, run go test -update if the change is intended", path, changed, total, diffPath)
+}
+
+// diffImages returns the number of pixels differing by more than
+// goldenChannelTolerance and an image with them in red over a faded golden.
+func diffImages(golden, img image.Image) (*image.NRGBA, int) {
+	bounds := golden.Bounds()
+	diff := image.NewNRGBA(bounds)
+	changed := 0
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			a := color.NRGBAModel.Convert(golden.At(x, y)).(color.NRGBA)
+			b := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+
+			if channelDiff(a, b) > goldenChannelTolerance {
+				changed++
+				diff.SetNRGBA(x, y, color.NRGBA{R: 0xff, A: 0xff})
+
+				continue
+			}
+
+			a.A /= 4
+			diff.SetNRGBA(x, y, a)
+		}
+	}
+
+	return diff, changed
+}
+
+func channelDiff(a, b color.NRGBA) int {
+	return max(abs(int(a.R)-int(b.R)), abs(int(a.G)-int(b.G)), abs(int(a.B)-int(b.B)), abs(int(a.A)-int(b.A)))
+}
+
+func writePNG(path string, img image.Image) error {
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+func TestDiffImages(t *testing.T) {
+	a := testImage(8, 8)
+	b := testImage(8, 8)
+
+	b.SetNRGBA(1, 1, color.NRGBA{R: a.NRGBAAt(1, 1).R ^ 0xff, A: 0xff})
+	b.Pix[b.PixOffset(2, 2)] ^= 1 // within the tolerance
+
+	if _, changed := diffImages(a, b); changed != 1 {
+		t.Errorf("Expected 1 changed pixel, but got %d", changed)
+	}
+}
@@ -0,0 +1,234 @@
+package stickerUpdater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	healthDecay          = 0.3
+	healthFailThreshold  = 3
+	healthSlowLatency    = 5 * time.Second
+	healthMinHealthScore = 0.5
+)
+
+// providerHealth keeps a moving error rate and latency for a single provider.
+type providerHealth struct {
+	errorRate     float64
+	latency       time.Duration
+	failures      int
+	cooldownUntil time.Time
+}
+
+// score returns 1 for a fast provider without recent errors and goes down to 0
+// as the error rate and latency grow.
+func (h *providerHealth) score() float64 {
+	score := 1 - h.errorRate
+
+	if h.latency > healthSlowLatency {
+		score -= 0.25
+	}
+
+	if score < 0 {
+		return 0
+	}
+
+	return score
+}
+
+// HealthTracker records outcomes of provider calls and puts failing providers
+// on a cool-down so the next one in the list is tried instead.
+type HealthTracker struct {
+	sync.Mutex
+	cooldown time.Duration
+	health   map[string]*providerHealth
+}
+
+func NewHealthTracker(cooldown time.Duration) *HealthTracker {
+	return &HealthTracker{
+		cooldown: cooldown,
+		health:   make(map[string]*providerHealth),
+	}
+}
+
+func (ht *HealthTracker) get(name string) *providerHealth {
+	h, ok := ht.health[name]
+	if !ok {
+		h = &providerHealth{}
+		ht.health[name] = h
+	}
+
+	return h
+}
+
+func (ht *HealthTracker) Report(name string, latency time.Duration, err error) {
+	ht.Lock()
+	defer ht.Unlock()
+
+	h := ht.get(name)
+
+	failed := 0.0
+	if err != nil {
+		failed = 1
+	}
+
+	h.errorRate = h.errorRate*(1-healthDecay) + failed*healthDecay
+	h.latency = time.Duration(float64(h.latency)*(1-healthDecay) + float64(latency)*healthDecay)
+
+	if err == nil {
+		h.failures = 0
+		h.cooldownUntil = time.Time{}
+
+		return
+	}
+
+	h.failures++
+	if h.failures >= healthFailThreshold {
+		h.cooldownUntil = time.Now().Add(ht.cooldown)
+	}
+}
+
+func (ht *HealthTracker) Available(name string) bool {
+	ht.Lock()
+	defer ht.Unlock()
+
+	return !time.Now().Before(ht.get(name).cooldownUntil)
+}
+
+func (ht *HealthTracker) Score(name string) float64 {
+	ht.Lock()
+	defer ht.Unlock()
+
+	return ht.get(name).score()
+}
+
+func (ht *HealthTracker) String() string {
+	ht.Lock()
+	defer ht.Unlock()
+
+	var result []string
+	for name, h := range ht.health {
+		result = append(result, fmt.Sprintf("%s: score %.2f, latency %s, failures %d", name, h.score(), h.latency.Round(time.Millisecond), h.failures))
+	}
+
+	return strings.Join(result, "; ")
+}
+
+// failoverProvider tries providers in the configured order, skipping the ones
+// on cool-down and moving unhealthy ones to the end of the list.
+type failoverProvider struct {
+	providers []MarketDataProvider
+	health    *HealthTracker
+}
+
+func newFailoverProvider(providers []MarketDataProvider, health *HealthTracker) *failoverProvider {
+	return &failoverProvider{
+		providers: providers,
+		health:    health,
+	}
+}
+
+func (fp *failoverProvider) Name() string {
+	var names []string
+	for _, provider := range fp.providers {
+		names = append(names, provider.Name())
+	}
+
+	return strings.Join(names, ",")
+}
+
+func (fp *failoverProvider) ordered(providers []MarketDataProvider) []MarketDataProvider {
+	var healthy, unhealthy, coolingDown []MarketDataProvider
+
+	for _, provider := range providers {
+		switch {
+		case !fp.health.Available(provider.Name()):
+			coolingDown = append(coolingDown, provider)
+		case fp.health.Score(provider.Name()) < healthMinHealthScore:
+			unhealthy = append(unhealthy, provider)
+		default:
+			healthy = append(healthy, provider)
+		}
+	}
+
+	result := append(healthy, unhealthy...)
+
+	// every provider is cooling down, try them anyway rather than do nothing
+	if len(result) == 0 {
+		return coolingDown
+	}
+
+	return result
+}
+
+func (fp *failoverProvider) GetPool(ctx context.Context, address string) (PoolSnapshot, error) {
+	var errs []error
+
+	for _, provider := range fp.ordered(fp.providers) {
+		start := time.Now()
+		snapshot, err := provider.GetPool(ctx, address)
+
+		// the caller gave up, this says nothing about the provider health
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return PoolSnapshot{}, errors.Join(append(errs, ctxErr)...)
+		}
+
+		fp.health.Report(provider.Name(), time.Since(start), err)
+
+		if err == nil {
+			return snapshot, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+	}
+
+	return PoolSnapshot{}, errors.Join(errs...)
+}
+
+func (fp *failoverProvider) GetCandles(ctx context.Context, address string, query CandleQuery) ([]Candle, error) {
+	var errs []error
+
+	for _, provider := range fp.ordered(fp.candleProviders()) {
+		start := time.Now()
+		candles, err := provider.GetCandles(ctx, address, query)
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, errors.Join(append(errs, ctxErr)...)
+		}
+
+		fp.health.Report(provider.Name(), time.Since(start), err)
+
+		if err == nil && len(candles) > 0 {
+			return candles, nil
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// candleProviders are the providers actually serving candles, so a failure is
+// charged to the one that failed rather than to a provider delegating to it.
+func (fp *failoverProvider) candleProviders() []MarketDataProvider {
+	var providers []MarketDataProvider
+
+	for _, provider := range fp.providers {
+		if delegate, ok := provider.(CandleDelegate); ok && delegate.CandleProvider() != nil {
+			provider = delegate.CandleProvider()
+		}
+
+		if !slices.ContainsFunc(providers, func(p MarketDataProvider) bool { return p.Name() == provider.Name() }) {
+			providers = append(providers, provider)
+		}
+	}
+
+	return providers
+}
@@ -0,0 +1,117 @@
+package stickerUpdater
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeProvider struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) GetPool(ctx context.Context, address string) (PoolSnapshot, error) {
+	p.calls++
+
+	if p.err != nil {
+		return PoolSnapshot{}, p.err
+	}
+
+	return PoolSnapshot{Name: p.name, Address: address}, nil
+}
+
+func (p *fakeProvider) GetCandles(ctx context.Context, address string, query CandleQuery) ([]Candle, error) {
+	p.calls++
+
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	return []Candle{{Time: time.Unix(0, 0)}}, nil
+}
+
+func TestFailoverProvider(t *testing.T) {
+	primary := &fakeProvider{name: "primary", err: errors.New("status code: 429")}
+	secondary := &fakeProvider{name: "secondary"}
+
+	provider := newFailoverProvider([]MarketDataProvider{primary, secondary}, NewHealthTracker(time.Minute))
+
+	// Test case 1: primary fails, data comes from secondary
+	snapshot, err := provider.GetPool(context.Background(), "pool")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if snapshot.Name != "secondary" {
+		t.Errorf("Expected snapshot from 'secondary', but got '%s'", snapshot.Name)
+	}
+
+	// Test case 2: primary is put on cool-down after repeated failures
+	for i := 0; i < healthFailThreshold; i++ {
+		_, _ = provider.GetPool(context.Background(), "pool")
+	}
+
+	callsBefore := primary.calls
+	_, _ = provider.GetPool(context.Background(), "pool")
+	if primary.calls != callsBefore {
+		t.Errorf("Expected primary to be skipped while cooling down, but it was called")
+	}
+
+	// Test case 3: every provider fails
+	secondary.err = errors.New("timeout")
+	if _, err := provider.GetPool(context.Background(), "pool"); err == nil {
+		t.Errorf("Expected error when all providers fail, but got nil")
+	}
+}
+
+func TestHealthTrackerRecovers(t *testing.T) {
+	health := NewHealthTracker(0)
+
+	for i := 0; i < healthFailThreshold; i++ {
+		health.Report("p", time.Millisecond, errors.New("fail"))
+	}
+
+	if health.Score("p") >= healthMinHealthScore {
+		t.Errorf("Expected low score after failures, but got %.2f", health.Score("p"))
+	}
+
+	for i := 0; i < 10; i++ {
+		health.Report("p", time.Millisecond, nil)
+	}
+
+	if health.Score("p") < healthMinHealthScore {
+		t.Errorf("Expected score to recover, but got %.2f", health.Score("p"))
+	}
+	if !health.Available("p") {
+		t.Errorf("Expected provider to be available after successful calls")
+	}
+}
+
+func TestFailoverProviderCandleDelegate(t *testing.T) {
+	gecko := &fakeProvider{name: "geckoterminal", err: errors.New("status code: 503")}
+	dexscreener := NewDexscreenerProvider(nil, "", gecko)
+	health := NewHealthTracker(time.Minute)
+
+	provider := newFailoverProvider([]MarketDataProvider{dexscreener, gecko}, health)
+
+	for range healthFailThreshold {
+		if _, err := provider.GetCandles(context.Background(), "pool", CandleQuery{}); err == nil {
+			t.Fatalf("Expected an error of the candles")
+		}
+	}
+
+	// tried once per call, not once for each provider delegating to it
+	if gecko.calls != healthFailThreshold {
+		t.Errorf("Expected %d calls of geckoterminal, but got %d", healthFailThreshold, gecko.calls)
+	}
+
+	if !health.Available("dexscreener") || health.Available("geckoterminal") {
+		t.Errorf("Expected geckoterminal on cool-down and dexscreener available, but got %s", health)
+	}
+}
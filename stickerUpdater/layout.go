@@ -0,0 +1,337 @@
+package stickerUpdater
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+//go:embed default_layout.json
+var defaultLayoutJSON []byte
+
+const layoutFileName = "layout.json"
+
+// Layout describes what is drawn over the sticker template: text blocks bound
+// to the pool snapshot and the regions the chart is drawn into.
+type Layout struct {
+	Font         string        `json:"font"`          // default font of the blocks
+	FontFallback []string      `json:"font_fallback"` // fonts tried for glyphs missing in the block font
+	Blocks       []TextBlock   `json:"blocks"`
+	Charts       []ChartRegion `json:"charts"`
+}
+
+// TextBlock is a text/template string drawn at X, Y. Text and Color may use
+// the fields of RenderData, e.g. "{{.PriceUSD}}" or "{{signColor .H1.PriceChange}}".
+// Color is a hex color or a theme color name, the theme text color when empty.
+type TextBlock struct {
+	Text        string  `json:"text"`
+	X           float64 `json:"x"`
+	Y           float64 `json:"y"`
+	AnchorX     float64 `json:"anchor_x"`
+	AnchorY     float64 `json:"anchor_y"`
+	Font        string  `json:"font"`
+	FontSize    float64 `json:"font_size"`
+	Color       string  `json:"color"`
+	Width       float64 `json:"width"`        // wrap width, the text is not wrapped when 0
+	LineSpacing float64 `json:"line_spacing"` // for wrapped text
+	Align       string  `json:"align"`        // left, center or right, for wrapped text
+
+	text  *template.Template
+	color *template.Template
+	// localized are copies of text and color with the functions of a locale,
+	// the layout is shared by tokens in different locales
+	localized *sync.Map
+}
+
+// ChartRegion is the rectangle the candle chart is drawn into.
+type ChartRegion struct {
+	X           int     `json:"x"`
+	Y           int     `json:"y"`
+	Width       int     `json:"width"`
+	Height      int     `json:"height"`
+	CandleWidth int     `json:"candle_width"`
+	Columns     int     `json:"columns"`
+	Rows        int     `json:"rows"`         // gridlines, 4 when 0
+	Grid        bool    `json:"grid"`         // horizontal gridlines
+	PriceLabels bool    `json:"price_labels"` // on the right of the chart
+	TimeLabels  bool    `json:"time_labels"`  // under the chart
+	LastPrice   bool    `json:"last_price"`   // line and tag at the last close
+	LabelSize   float64 `json:"label_size"`   // 12 when 0
+	GridColor   string  `json:"grid_color"`   // faded theme text color when empty
+	LabelColor  string  `json:"label_color"`  // faded theme text color when empty
+
+	gridColor  *color.NRGBA
+	labelColor *color.NRGBA
+}
+
+// RenderData is what text blocks are bound to.
+type RenderData struct {
+	PoolSnapshot
+	Token string
+	Emoji string
+	Now   time.Time
+}
+
+// layoutFuncs are the template functions, the number and date ones are
+// replaced by the locale of the token when drawn.
+var layoutFuncs = template.FuncMap{
+	"comma":   Locales[DefaultLocale].comma,
+	"commaf":  Locales[DefaultLocale].commaf,
+	"price":   Locales[DefaultLocale].price,
+	"compact": Locales[DefaultLocale].compact,
+	"change":  Locales[DefaultLocale].change,
+	"date":    Locales[DefaultLocale].date,
+	"abs":     math.Abs,
+	"signColor": func(value float64) string {
+		switch {
+		case value > 0:
+			return "positive"
+		case value < 0:
+			return "negative"
+		}
+
+		return "neutral"
+	},
+}
+
+func toInt64(value any) int64 {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+
+	return 0
+}
+
+// loadLayout reads layout.json from dir, falling back to the global layout.
+func loadLayout(dir string, fallback *Layout) (*Layout, error) {
+	data, err := os.ReadFile(dir + "/" + layoutFileName)
+	if os.IsNotExist(err) && fallback != nil {
+		return fallback, nil
+	}
+
+	if os.IsNotExist(err) {
+		data = defaultLayoutJSON
+	} else if err != nil {
+		return nil, err
+	}
+
+	return parseLayout(data)
+}
+
+func parseLayout(data []byte) (*Layout, error) {
+	layout := &Layout{}
+	if err := json.Unmarshal(data, layout); err != nil {
+		return nil, fmt.Errorf("parse layout: %w", err)
+	}
+
+	for i := range layout.Blocks {
+		block := &layout.Blocks[i]
+		block.localized = &sync.Map{}
+
+		var err error
+
+		block.text, err = template.New("text").Funcs(layoutFuncs).Parse(block.Text)
+		if err != nil {
+			return nil, fmt.Errorf("block %d text: %w", i, err)
+		}
+
+		block.color, err = template.New("color").Funcs(layoutFuncs).Parse(block.Color)
+		if err != nil {
+			return nil, fmt.Errorf("block %d color: %w", i, err)
+		}
+	}
+
+	for i := range layout.Charts {
+		region := &layout.Charts[i]
+
+		if region.GridColor != "" {
+			gridColor, err := parseNRGBA(region.GridColor)
+			if err != nil {
+				return nil, fmt.Errorf("chart %d grid color: %w", i, err)
+			}
+
+			region.gridColor = &gridColor
+		}
+
+		if region.LabelColor != "" {
+			labelColor, err := parseNRGBA(region.LabelColor)
+			if err != nil {
+				return nil, fmt.Errorf("chart %d label color: %w", i, err)
+			}
+
+			region.labelColor = &labelColor
+		}
+	}
+
+	return layout, nil
+}
+
+// fonts returns the fallback chain of font names for the block.
+func (layout *Layout) fonts(block *TextBlock) []string {
+	primary := block.Font
+	if primary == "" {
+		primary = layout.Font
+	}
+
+	return append([]string{primary}, layout.FontFallback...)
+}
+
+// templates returns text and color with the functions of locale.
+func (block *TextBlock) templates(locale *Locale) (*template.Template, *template.Template, error) {
+	if localized, ok := block.localized.Load(locale); ok {
+		pair := localized.([2]*template.Template)
+		return pair[0], pair[1], nil
+	}
+
+	text, err := block.text.Clone()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	colorTemplate, err := block.color.Clone()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pair := [2]*template.Template{text.Funcs(locale.funcs()), colorTemplate.Funcs(locale.funcs())}
+	block.localized.Store(locale, pair)
+
+	return pair[0], pair[1], nil
+}
+
+func (block *TextBlock) draw(dc *gg.Context, data RenderData, theme *Theme, locale *Locale) error {
+	textTemplate, colorTemplate, err := block.templates(locale)
+	if err != nil {
+		return err
+	}
+
+	text, err := execute(textTemplate, data)
+	if err != nil {
+		return err
+	}
+
+	colorValue, err := execute(colorTemplate, data)
+	if err != nil {
+		return err
+	}
+
+	blockColor, err := theme.color(colorValue)
+	if err != nil {
+		return err
+	}
+
+	dc.SetColor(blockColor)
+
+	if block.Width > 0 {
+		lineSpacing := block.LineSpacing
+		if lineSpacing == 0 {
+			lineSpacing = 1
+		}
+
+		dc.DrawStringWrapped(text, block.X, block.Y, block.AnchorX, block.AnchorY, block.Width, lineSpacing, parseAlign(block.Align))
+
+		return nil
+	}
+
+	dc.DrawStringAnchored(text, block.X, block.Y, block.AnchorX, block.AnchorY)
+
+	return nil
+}
+
+func execute(tmpl *template.Template, data RenderData) (string, error) {
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func parseAlign(align string) gg.Align {
+	switch align {
+	case "center":
+		return gg.AlignCenter
+	case "right":
+		return gg.AlignRight
+	}
+
+	return gg.AlignLeft
+}
+
+// parseHexColor parses #rgb, #rrggbb and #rrggbbaa colors, empty is white.
+func parseHexColor(value string) (color.Color, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "#")
+
+	switch len(value) {
+	case 0:
+		return color.White, nil
+	case 3:
+		value = string([]byte{value[0], value[0], value[1], value[1], value[2], value[2]}) + "ff"
+	case 6:
+		value += "ff"
+	case 8:
+	default:
+		return nil, fmt.Errorf("invalid color %q", value)
+	}
+
+	rgba, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid color %q", value)
+	}
+
+	return color.NRGBA{R: uint8(rgba >> 24), G: uint8(rgba >> 16), B: uint8(rgba >> 8), A: uint8(rgba)}, nil
+}
+
+// labels tells whether the region draws any text.
+func (region ChartRegion) labels() bool {
+	return region.PriceLabels || region.TimeLabels || region.LastPrice
+}
+
+func (region ChartRegion) options(theme *Theme, locale *Locale, location *time.Location) Options {
+	gridColor := theme.faded(defaultGridAlpha)
+	if region.gridColor != nil {
+		gridColor = *region.gridColor
+	}
+
+	labelColor := theme.faded(defaultLabelAlpha)
+	if region.labelColor != nil {
+		labelColor = *region.labelColor
+	}
+
+	return Options{
+		XOffset:     region.X,
+		YOffset:     region.Y,
+		Width:       region.X + region.Width,
+		Height:      region.Y + region.Height,
+		CandleWidth: region.CandleWidth,
+		Columns:     max(region.Columns, 1),
+		Rows:        region.Rows,
+		Axes: Axes{
+			Grid:        region.Grid,
+			PriceLabels: region.PriceLabels,
+			TimeLabels:  region.TimeLabels,
+			LastPrice:   region.LastPrice,
+			GridColor:   gridColor,
+			LabelColor:  labelColor,
+			Locale:      locale,
+			Location:    location,
+		},
+		Theme: theme,
+	}
+}
@@ -0,0 +1,60 @@
+package stickerUpdater
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestParseDefaultLayout(t *testing.T) {
+	layout, err := parseLayout(defaultLayoutJSON)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if len(layout.Blocks) == 0 || len(layout.Charts) == 0 {
+		t.Fatalf("Expected blocks and charts in the default layout, but got %+v", layout)
+	}
+
+	text, err := execute(layout.Blocks[3].color, RenderData{PoolSnapshot: PoolSnapshot{M5: Window{PriceChange: -1}}})
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if text != "negative" {
+		t.Errorf("Expected negative color, but got %q", text)
+	}
+
+	resolved, err := Themes["dark"].color(text)
+	if err != nil || resolved != (color.NRGBA{R: 208, G: 2, B: 27, A: 255}) {
+		t.Errorf("Expected #d0021b, but got %v (%v)", resolved, err)
+	}
+}
+
+func TestParseLayoutInvalidTemplate(t *testing.T) {
+	if _, err := parseLayout([]byte(`{"blocks": [{"text": "{{.Name"}]}`)); err == nil {
+		t.Errorf("Expected error for invalid template, but got nil")
+	}
+}
+
+func TestParseHexColor(t *testing.T) {
+	tests := map[string]color.NRGBA{
+		"#fff":      {R: 255, G: 255, B: 255, A: 255},
+		"#7ed321":   {R: 126, G: 211, B: 33, A: 255},
+		"#d0021b80": {R: 208, G: 2, B: 27, A: 128},
+	}
+
+	for value, expected := range tests {
+		result, err := parseHexColor(value)
+		if err != nil {
+			t.Errorf("%s: expected no error, but got %v", value, err)
+			continue
+		}
+
+		if result != expected {
+			t.Errorf("%s: expected %v, but got %v", value, expected, result)
+		}
+	}
+
+	if _, err := parseHexColor("#12"); err == nil {
+		t.Errorf("Expected error for invalid color, but got nil")
+	}
+}
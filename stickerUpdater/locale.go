@@ -0,0 +1,144 @@
+package stickerUpdater
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+const DefaultLocale = "en"
+
+// Locale is how numbers and dates are written on the sticker.
+type Locale struct {
+	ThousandsSeparator string
+	DecimalSeparator   string
+	CompactUnits       []string // suffixes of thousands, millions, billions and trillions
+	DateFormat         string   // time.Format layout of the date template function
+
+	replacer *strings.Replacer
+}
+
+// Locales are the locales selectable with LOCALE, CHAT_LOCALES or the token
+// locale.
+var Locales = map[string]*Locale{
+	"en": {
+		ThousandsSeparator: ",",
+		DecimalSeparator:   ".",
+		CompactUnits:       []string{"K", "M", "B", "T"},
+		DateFormat:         "02 Jan 06 15:04 MST",
+	},
+	"ru": {
+		ThousandsSeparator: "\u00a0",
+		DecimalSeparator:   ",",
+		CompactUnits:       []string{"\u00a0тыс.", "\u00a0млн", "\u00a0млрд", "\u00a0трлн"},
+		DateFormat:         "02.01.06 15:04 MST",
+	},
+	"de": {
+		ThousandsSeparator: ".",
+		DecimalSeparator:   ",",
+		CompactUnits:       []string{"\u00a0Tsd.", "\u00a0Mio.", "\u00a0Mrd.", "\u00a0Bio."},
+		DateFormat:         "02.01.06 15:04 MST",
+	},
+}
+
+func init() {
+	for _, locale := range Locales {
+		locale.replacer = strings.NewReplacer(",", locale.ThousandsSeparator, ".", locale.DecimalSeparator)
+	}
+}
+
+func validLocale(name string) error {
+	if _, ok := Locales[name]; !ok {
+		return fmt.Errorf("unknown locale %q", name)
+	}
+
+	return nil
+}
+
+// localize replaces the separators of a number formatted in English.
+func (locale *Locale) localize(number string) string {
+	return locale.replacer.Replace(number)
+}
+
+func (locale *Locale) comma(value any) string {
+	return locale.localize(humanize.Comma(toInt64(value)))
+}
+
+func (locale *Locale) commaf(value float64, digits int) string {
+	return locale.localize(humanize.CommafWithDigits(value, digits))
+}
+
+func (locale *Locale) price(value float64) string {
+	return locale.localize(formatPrice(value))
+}
+
+// compact writes large numbers with a unit and one decimal: 1.2K, 3.4M.
+func (locale *Locale) compact(value any) string {
+	number := toFloat64(value)
+
+	// 999.96 is written as 1K rather than 1,000
+	scaled, unit := math.Abs(number), -1
+	for scaled >= 999.95 && unit+1 < len(locale.CompactUnits) {
+		scaled /= 1000
+		unit++
+	}
+
+	if unit < 0 {
+		return locale.commaf(number, 2)
+	}
+
+	return locale.commaf(math.Copysign(math.Round(scaled*10)/10, number), 1) + locale.CompactUnits[unit]
+}
+
+// change writes a change in percent with its sign: +1.23%, -0.50%.
+func (locale *Locale) change(value float64) string {
+	return locale.localize(fmt.Sprintf("%+.2f", value)) + "%"
+}
+
+func (locale *Locale) date(value time.Time) string {
+	return value.Format(locale.DateFormat)
+}
+
+// funcs are the template functions formatting numbers in the locale.
+func (locale *Locale) funcs() template.FuncMap {
+	return template.FuncMap{
+		"comma":   locale.comma,
+		"commaf":  locale.commaf,
+		"price":   locale.price,
+		"compact": locale.compact,
+		"change":  locale.change,
+		"date":    locale.date,
+	}
+}
+
+func toFloat64(value any) float64 {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+
+	return 0
+}
+
+// localization returns the locale and time zone of the token, the defaults
+// before it's loaded.
+func (stickerConfig *StickerConfig) localization() (*Locale, *time.Location) {
+	locale, location := stickerConfig.locale, stickerConfig.location
+	if locale == nil {
+		locale = Locales[DefaultLocale]
+	}
+
+	if location == nil {
+		location = time.UTC
+	}
+
+	return locale, location
+}
@@ -0,0 +1,86 @@
+package stickerUpdater
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocaleNumbers(t *testing.T) {
+	en, ru := Locales["en"], Locales["ru"]
+
+	tests := []struct {
+		result, expected string
+	}{
+		{en.comma(1234567), "1,234,567"},
+		{ru.comma(1234567), "1\u00a0234\u00a0567"},
+		{en.commaf(1234.5678, 2), "1,234.56"},
+		{ru.commaf(1234.5678, 2), "1\u00a0234,56"},
+		{ru.price(0.0012346), "0,001235"},
+		{ru.price(0.0000012346), "0,0₅1235"},
+		{en.compact(999.5), "999.5"},
+		{en.compact(1234), "1.2K"},
+		{en.compact(999960), "1M"},
+		{en.compact(-2500000), "-2.5M"},
+		{ru.compact(1200), "1,2\u00a0тыс."},
+		{ru.compact(8510000), "8,5\u00a0млн"},
+		{en.compact(5e15), "5,000T"},
+	}
+
+	for _, test := range tests {
+		if test.result != test.expected {
+			t.Errorf("Expected %q, but got %q", test.expected, test.result)
+		}
+	}
+}
+
+func TestLocalizedTemplates(t *testing.T) {
+	layout, err := parseLayout([]byte(`{"blocks": [{"text": "{{comma .FdvUSD}} {{compact .FdvUSD}} {{change .H24.PriceChange}} {{date .Now}}"}]}`))
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	data := RenderData{PoolSnapshot: PoolSnapshot{FdvUSD: 1234567, H24: Window{PriceChange: -0.5}}, Now: time.Date(2024, time.April, 6, 10, 30, 0, 0, time.UTC).In(moscow)}
+	block := &layout.Blocks[0]
+
+	expected := map[string]string{
+		"en": "1,234,567 1.2M -0.50% 06 Apr 24 13:30 MSK",
+		"ru": "1\u00a0234\u00a0567 1,2\u00a0млн -0,50% 06.04.24 13:30 MSK",
+	}
+
+	// twice, the second time from the cache
+	for range 2 {
+		for name, text := range expected {
+			textTemplate, _, err := block.templates(Locales[name])
+			if err != nil {
+				t.Fatalf("Expected no error, but got %v", err)
+			}
+
+			result, err := execute(textTemplate, data)
+			if err != nil {
+				t.Fatalf("Expected no error, but got %v", err)
+			}
+
+			if result != text {
+				t.Errorf("%s: expected %q, but got %q", name, text, result)
+			}
+		}
+	}
+}
+
+func TestInitChartLocale(t *testing.T) {
+	if err := (&StickerConfig{Locale: "ru", Timezone: "Europe/Moscow"}).initChart(); err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+
+	invalid := []*StickerConfig{{Locale: "xx"}, {Timezone: "Mars/Olympus"}}
+	for _, stickerConfig := range invalid {
+		if err := stickerConfig.initChart(); err == nil {
+			t.Errorf("Expected error for %+v, but got nil", stickerConfig)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package stickerUpdater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ad/anonstickerbot/config"
+	"github.com/ad/anonstickerbot/sender"
+)
+
+// RenderAddress renders the sticker of a pool or jetton address missing from
+// TOKENS_PATH on the generic template and uploads it to the target chat. A
+// jetton is shown by its most traded pool.
+func (su *StickerUpdater) RenderAddress(ctx context.Context, address string) (sender.InlineSticker, error) {
+	ctx, cancel := config.WithTimeout(ctx, su.config.UPDATE_TIMEOUT)
+	defer cancel()
+
+	stickerConfig := su.addressSticker(address)
+
+	data, candles, err := su.fetchData(ctx, stickerConfig)
+	if err != nil {
+		pool, findErr := su.findPool(ctx, address)
+		if findErr != nil {
+			return sender.InlineSticker{}, errors.Join(err, findErr)
+		}
+
+		stickerConfig = su.addressSticker(pool)
+		if data, candles, err = su.fetchData(ctx, stickerConfig); err != nil {
+			return sender.InlineSticker{}, err
+		}
+	}
+
+	if data.Name != "" {
+		stickerConfig.Name = data.Name
+	}
+
+	stickerData, filename, err := su.encodeSticker(ctx, stickerConfig, data, candles)
+	if err != nil {
+		return sender.InlineSticker{}, err
+	}
+
+	inlineSticker, err := su.uploadSticker(ctx, stickerConfig, data, stickerData, filename)
+	if err != nil {
+		return sender.InlineSticker{}, err
+	}
+
+	// found by the address the user typed, even when it's the jetton one
+	inlineSticker.Address = address
+
+	return inlineSticker, nil
+}
+
+// addressSticker is the generic sticker of the pool at address.
+func (su *StickerUpdater) addressSticker(address string) *StickerConfig {
+	stickerConfig := *su.generic
+	stickerConfig.Name = address
+	stickerConfig.Address = address
+
+	return &stickerConfig
+}
+
+// findPool looks up the pool of a jetton with the first data provider able to.
+func (su *StickerUpdater) findPool(ctx context.Context, tokenAddress string) (string, error) {
+	names := append(strings.Split(su.config.DATA_PROVIDER, ","), DefaultProvider)
+
+	for _, name := range names {
+		finder, ok := su.providers[strings.ToLower(strings.TrimSpace(name))].(PoolFinder)
+		if !ok {
+			continue
+		}
+
+		pool, err := finder.FindPool(ctx, tokenAddress)
+		if err != nil {
+			return "", fmt.Errorf("%s find pool error: %w", tokenAddress, err)
+		}
+
+		return pool, nil
+	}
+
+	return "", fmt.Errorf("%s: no data provider finds pools of jettons", tokenAddress)
+}
@@ -0,0 +1,323 @@
+package stickerUpdater
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"time"
+)
+
+const (
+	OverlaySMA       = "sma"
+	OverlayEMA       = "ema"
+	OverlayVWAP      = "vwap"
+	OverlayBollinger = "bollinger"
+
+	defaultOverlayPeriod = 20
+	defaultBollingerDev  = 2
+	defaultVolumeShare   = 0.2 // of the chart height
+	volumeGap            = 4
+	volumeAlpha          = 110
+)
+
+var defaultOverlayColors = map[string]string{
+	OverlaySMA:       "#ffcc00",
+	OverlayEMA:       "#00bfff",
+	OverlayVWAP:      "#ff66ff",
+	OverlayBollinger: "#aaaaaa",
+}
+
+// VolumePanel draws volume bars under the price chart.
+type VolumePanel struct {
+	Height int    `json:"height"` // taken from the bottom of the chart, 20% of it when 0
+	Color  string `json:"color"`  // candle colors when empty
+
+	color *color.NRGBA
+}
+
+// Overlay is an indicator drawn over the price chart.
+type Overlay struct {
+	Type   string  `json:"type"`   // sma, ema, vwap or bollinger
+	Period int     `json:"period"` // 20 when 0, not used by vwap
+	StdDev float64 `json:"stddev"` // width of the bollinger bands, 2 when 0
+	Color  string  `json:"color"`
+
+	color color.NRGBA
+}
+
+// initChart validates the rendering settings of the token and parses its colors.
+func (stickerConfig *StickerConfig) initChart() error {
+	if !validChartType(stickerConfig.ChartType) {
+		return fmt.Errorf("unknown chart type %q", stickerConfig.ChartType)
+	}
+
+	switch stickerConfig.Animation {
+	case "", AnimationDraw, AnimationCountUp:
+	default:
+		return fmt.Errorf("unknown animation %q", stickerConfig.Animation)
+	}
+
+	if stickerConfig.Theme != "" {
+		if err := validTheme(stickerConfig.Theme); err != nil {
+			return err
+		}
+	}
+
+	if stickerConfig.Locale != "" {
+		if err := validLocale(stickerConfig.Locale); err != nil {
+			return err
+		}
+	}
+
+	if stickerConfig.Timezone != "" {
+		if _, err := time.LoadLocation(stickerConfig.Timezone); err != nil {
+			return fmt.Errorf("timezone: %w", err)
+		}
+	}
+
+	if _, ok := Timeframes[stickerConfig.Timeframe]; !ok && stickerConfig.Timeframe != "" {
+		return fmt.Errorf("unknown timeframe %q", stickerConfig.Timeframe)
+	}
+
+	if stickerConfig.Candles < 0 || stickerConfig.Candles > MaxCandles {
+		return fmt.Errorf("candles %d, at most %d", stickerConfig.Candles, MaxCandles)
+	}
+
+	if volume := stickerConfig.Volume; volume != nil {
+		if volume.Height < 0 {
+			return fmt.Errorf("volume height %d", volume.Height)
+		}
+
+		if volume.Color != "" {
+			volumeColor, err := parseNRGBA(volume.Color)
+			if err != nil {
+				return fmt.Errorf("volume: %w", err)
+			}
+
+			volume.color = &volumeColor
+		}
+	}
+
+	for i := range stickerConfig.Overlays {
+		overlay := &stickerConfig.Overlays[i]
+
+		colorValue, ok := defaultOverlayColors[overlay.Type]
+		if !ok {
+			return fmt.Errorf("unknown overlay %q", overlay.Type)
+		}
+
+		if overlay.Period < 0 || overlay.StdDev < 0 {
+			return fmt.Errorf("overlay %s: negative period or stddev", overlay.Type)
+		}
+
+		if overlay.Period == 0 {
+			overlay.Period = defaultOverlayPeriod
+		}
+
+		if overlay.StdDev == 0 {
+			overlay.StdDev = defaultBollingerDev
+		}
+
+		if overlay.Color != "" {
+			colorValue = overlay.Color
+		}
+
+		var err error
+
+		overlay.color, err = parseNRGBA(colorValue)
+		if err != nil {
+			return fmt.Errorf("overlay %s: %w", overlay.Type, err)
+		}
+	}
+
+	return nil
+}
+
+func parseNRGBA(value string) (color.NRGBA, error) {
+	parsed, err := parseHexColor(value)
+	if err != nil {
+		return color.NRGBA{}, err
+	}
+
+	return color.NRGBAModel.Convert(parsed).(color.NRGBA), nil
+}
+
+// volumeHeight returns the height of the volume panel in opts, 0 without one.
+func volumeHeight(opts Options) int {
+	if opts.Volume == nil {
+		return 0
+	}
+
+	if opts.Volume.Height > 0 {
+		return opts.Volume.Height
+	}
+
+	return int(float64(opts.Height-opts.YOffset) * defaultVolumeShare)
+}
+
+// drawVolume draws the volume of the candles as bars growing up from
+// bottom, the highest volume of all candles fills the panel.
+func drawVolume(img *image.NRGBA, data []Candle, visible int, scale chartScale, opts Options, bottom, panelHeight int) {
+	height := panelHeight - volumeGap
+	if height <= 0 {
+		return
+	}
+
+	maxVolume := 0.0
+	for _, d := range data {
+		maxVolume = math.Max(maxVolume, d.Volume)
+	}
+
+	if maxVolume == 0 {
+		return
+	}
+
+	halfCandleWidth := opts.CandleWidth / 2
+
+	for _, d := range data[:visible] {
+		barColor := color.NRGBA{A: volumeAlpha}
+		if opts.Volume.color != nil {
+			barColor = *opts.Volume.color
+		} else {
+			candleColor := opts.Theme.candleColor(d.Open, d.Close)
+			barColor.R, barColor.G, barColor.B = candleColor.R, candleColor.G, candleColor.B
+		}
+
+		x := scale.x(d.Time)
+		top := bottom - int(float64(height)*d.Volume/maxVolume)
+
+		for y := top; y <= bottom; y++ {
+			for xx := x - halfCandleWidth; xx <= x+halfCandleWidth; xx++ {
+				blend(img, xx, y, barColor)
+			}
+		}
+	}
+}
+
+// drawOverlays draws the indicators over the first visible candles. They are
+// calculated from all candles, so they don't change while candles are drawn in.
+func drawOverlays(img *image.NRGBA, data []Candle, visible int, scale chartScale, overlays []Overlay) {
+	closes := make([]float64, len(data))
+	for i, d := range data {
+		closes[i] = d.Close
+	}
+
+	for _, overlay := range overlays {
+		switch overlay.Type {
+		case OverlaySMA:
+			drawSeries(img, data, sma(closes, overlay.Period), visible, scale, overlay.color)
+		case OverlayEMA:
+			drawSeries(img, data, ema(closes, overlay.Period), visible, scale, overlay.color)
+		case OverlayVWAP:
+			drawSeries(img, data, vwap(data), visible, scale, overlay.color)
+		case OverlayBollinger:
+			middle, upper, lower := bollinger(closes, overlay.Period, overlay.StdDev)
+
+			bandColor := overlay.color
+			bandColor.A /= 2
+
+			drawSeries(img, data, middle, visible, scale, bandColor)
+			drawSeries(img, data, upper, visible, scale, overlay.color)
+			drawSeries(img, data, lower, visible, scale, overlay.color)
+		}
+	}
+}
+
+// drawSeries connects the defined values of series, clipped to the chart.
+func drawSeries(img *image.NRGBA, data []Candle, series []float64, visible int, scale chartScale, col color.NRGBA) {
+	var previous *image.Point
+
+	for i := range visible {
+		if math.IsNaN(series[i]) {
+			previous = nil
+			continue
+		}
+
+		y := min(max(scale.y(series[i]), scale.chartHmax), scale.chartHmin)
+		point := image.Point{X: scale.x(data[i].Time), Y: y}
+
+		if previous != nil {
+			thickLine(img, *previous, point, 1, col)
+		}
+
+		previous = &point
+	}
+}
+
+// sma is the simple moving average, NaN until period values are known.
+func sma(values []float64, period int) []float64 {
+	result := make([]float64, len(values))
+	sum := 0.0
+
+	for i, value := range values {
+		sum += value
+		if i >= period {
+			sum -= values[i-period]
+		}
+
+		result[i] = math.NaN()
+		if i >= period-1 {
+			result[i] = sum / float64(period)
+		}
+	}
+
+	return result
+}
+
+// ema is the exponential moving average seeded with the sma of the first
+// period values.
+func ema(values []float64, period int) []float64 {
+	result := sma(values, period)
+	k := 2 / float64(period+1)
+
+	for i := period; i < len(values); i++ {
+		result[i] = values[i]*k + result[i-1]*(1-k)
+	}
+
+	return result
+}
+
+// vwap is the volume weighted average of the typical price since the first
+// candle, NaN until there is any volume.
+func vwap(data []Candle) []float64 {
+	result := make([]float64, len(data))
+
+	var priceVolume, volume float64
+
+	for i, d := range data {
+		priceVolume += (d.High + d.Low + d.Close) / 3 * d.Volume
+		volume += d.Volume
+
+		result[i] = math.NaN()
+		if volume > 0 {
+			result[i] = priceVolume / volume
+		}
+	}
+
+	return result
+}
+
+// bollinger returns the sma and the bands stdDev standard deviations around it.
+func bollinger(values []float64, period int, stdDev float64) ([]float64, []float64, []float64) {
+	middle := sma(values, period)
+	upper := make([]float64, len(values))
+	lower := make([]float64, len(values))
+
+	for i := range values {
+		if math.IsNaN(middle[i]) {
+			upper[i], lower[i] = math.NaN(), math.NaN()
+			continue
+		}
+
+		variance := 0.0
+		for _, value := range values[i-period+1 : i+1] {
+			variance += (value - middle[i]) * (value - middle[i])
+		}
+
+		deviation := math.Sqrt(variance/float64(period)) * stdDev
+		upper[i], lower[i] = middle[i]+deviation, middle[i]-deviation
+	}
+
+	return middle, upper, lower
+}
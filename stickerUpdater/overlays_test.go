@@ -0,0 +1,72 @@
+package stickerUpdater
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMovingAverages(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5}
+
+	average := sma(values, 3)
+	if !math.IsNaN(average[1]) || average[2] != 2 || average[4] != 4 {
+		t.Errorf("Expected [NaN NaN 2 3 4], but got %v", average)
+	}
+
+	exponential := ema(values, 3)
+	if exponential[2] != 2 || exponential[3] != 3 || exponential[4] != 4 {
+		t.Errorf("Expected [NaN NaN 2 3 4], but got %v", exponential)
+	}
+}
+
+func TestBollinger(t *testing.T) {
+	middle, upper, lower := bollinger([]float64{2, 4, 2, 4}, 2, 2)
+
+	if middle[1] != 3 || upper[1] != 5 || lower[1] != 1 {
+		t.Errorf("Expected 3, 5 and 1, but got %f, %f and %f", middle[1], upper[1], lower[1])
+	}
+
+	if !math.IsNaN(upper[0]) {
+		t.Errorf("Expected NaN before the first full period, but got %f", upper[0])
+	}
+}
+
+func TestVWAP(t *testing.T) {
+	result := vwap([]Candle{
+		{High: 3, Low: 3, Close: 3, Volume: 0},
+		{High: 2, Low: 2, Close: 2, Volume: 1},
+		{High: 5, Low: 5, Close: 5, Volume: 3},
+	})
+
+	if !math.IsNaN(result[0]) || result[1] != 2 || result[2] != 4.25 {
+		t.Errorf("Expected [NaN 2 4.25], but got %v", result)
+	}
+}
+
+func TestInitChart(t *testing.T) {
+	stickerConfig := &StickerConfig{Animation: AnimationDraw, Overlays: []Overlay{{Type: OverlayEMA}}, Volume: &VolumePanel{Color: "#ff000080"}}
+	if err := stickerConfig.initChart(); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if stickerConfig.Overlays[0].Period != defaultOverlayPeriod || stickerConfig.Overlays[0].color.B != 0xff {
+		t.Errorf("Expected default period and color, but got %+v", stickerConfig.Overlays[0])
+	}
+
+	if stickerConfig.Volume.color == nil || stickerConfig.Volume.color.A != 0x80 {
+		t.Errorf("Expected volume color, but got %+v", stickerConfig.Volume.color)
+	}
+
+	invalid := []*StickerConfig{
+		{ChartType: "renko"},
+		{Animation: "countdown"},
+		{Overlays: []Overlay{{Type: "macd"}}},
+		{Overlays: []Overlay{{Type: OverlaySMA, Color: "#12"}}},
+	}
+
+	for _, stickerConfig := range invalid {
+		if err := stickerConfig.initChart(); err == nil {
+			t.Errorf("Expected error for %+v, but got nil", stickerConfig)
+		}
+	}
+}
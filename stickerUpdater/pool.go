@@ -0,0 +1,84 @@
+package stickerUpdater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/ad/anonstickerbot/config"
+)
+
+// run updates a single token on the worker pool, bounded by UPDATE_CONCURRENCY
+// and limited to UPDATE_TIMEOUT. It reports false without doing anything when
+// the previous update of the same token is still in progress.
+func (su *StickerUpdater) run(ctx context.Context, stickerConfig *StickerConfig) (bool, error) {
+	su.stateMutex.Lock()
+	if _, ok := su.running[stickerConfig.Name]; ok {
+		su.stateMutex.Unlock()
+
+		if su.config.Debug {
+			fmt.Printf("%s is still updating, skipped\n", stickerConfig.Name)
+		}
+
+		return false, nil
+	}
+	su.running[stickerConfig.Name] = struct{}{}
+	su.stateMutex.Unlock()
+
+	defer func() {
+		su.stateMutex.Lock()
+		delete(su.running, stickerConfig.Name)
+		su.stateMutex.Unlock()
+	}()
+
+	select {
+	case su.workers <- struct{}{}:
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+
+	defer func() { <-su.workers }()
+
+	ctx, cancel := config.WithTimeout(ctx, su.config.UPDATE_TIMEOUT)
+	defer cancel()
+
+	return true, su.updateSticker(ctx, stickerConfig)
+}
+
+// RunAll updates every token on the worker pool and waits for all of them.
+func (su *StickerUpdater) RunAll(ctx context.Context) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	su.stateMutex.Lock()
+	stickerConfigs := slices.Collect(maps.Values(su.stickers))
+	su.stateMutex.Unlock()
+
+	for _, stickerConfig := range stickerConfigs {
+		wg.Add(1)
+
+		go func(stickerConfig *StickerConfig) {
+			defer wg.Done()
+
+			if _, err := su.run(ctx, stickerConfig); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(stickerConfig)
+	}
+
+	wg.Wait()
+
+	if su.config.Debug {
+		fmt.Printf("providers health: %s\n", su.health)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,130 @@
+package stickerUpdater
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	PreviewPNG  = "png"
+	PreviewWebP = "webp"
+	PreviewWebM = "webm" // the animation of the token
+)
+
+// Fixture is market data read from a file instead of a provider, fields are
+// named like PoolSnapshot and Candle:
+//
+//	{"pool": {"Name": "ANON / TON", "PriceUSD": 0.0085, "M5": {"PriceChange": -1.2}},
+//	 "candles": [{"Time": "2024-04-06T10:00:00Z", "Open": 0.0084, "High": 0.0086, "Low": 0.0083, "Close": 0.0085}]}
+type Fixture struct {
+	Pool    PoolSnapshot `json:"pool"`
+	Candles []Candle     `json:"candles"`
+}
+
+func LoadFixture(path string) (*Fixture, error) {
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture := &Fixture{}
+	if err := json.Unmarshal(file, fixture); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return fixture, nil
+}
+
+// Preview renders the sticker of token the way it's sent, without sending it.
+// Token is a sticker name or a token directory with a single sticker. Data
+// is fetched from the providers without fixture.
+func (su *StickerUpdater) Preview(ctx context.Context, token string, fixture *Fixture, format string) ([]byte, error) {
+	stickerConfig, err := su.findSticker(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		data    PoolSnapshot
+		candles []Candle
+	)
+
+	if fixture != nil {
+		data, candles = fixture.Pool, fixture.Candles
+	} else if data, candles, err = su.fetchData(ctx, stickerConfig); err != nil {
+		return nil, err
+	}
+
+	if format == PreviewWebM {
+		if stickerConfig.Animation == "" {
+			return nil, fmt.Errorf("%s has no animation", stickerConfig.Name)
+		}
+
+		return su.animatedSticker(ctx, stickerConfig, data, candles)
+	}
+
+	img, err := su.render(stickerConfig, data, candles)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%s render error: %w", stickerConfig.Name, stickerConfig.Address, err)
+	}
+
+	switch format {
+	case PreviewPNG:
+		buf := new(bytes.Buffer)
+		if err := png.Encode(buf, img); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+	case PreviewWebP:
+		return encodeWebP(ctx, su.config.WEBP_ENCODER, img)
+	}
+
+	return nil, fmt.Errorf("unknown preview format %q", format)
+}
+
+// findSticker looks token up by sticker name ignoring case, then by token
+// directory.
+func (su *StickerUpdater) findSticker(token string) (*StickerConfig, error) {
+	su.stateMutex.Lock()
+	defer su.stateMutex.Unlock()
+
+	if stickerConfig, ok := su.stickers[token]; ok {
+		return stickerConfig, nil
+	}
+
+	var inDir []*StickerConfig
+
+	for name, stickerConfig := range su.stickers {
+		if strings.EqualFold(name, token) {
+			return stickerConfig, nil
+		}
+
+		if filepath.Base(stickerConfig.dir) == token {
+			inDir = append(inDir, stickerConfig)
+		}
+	}
+
+	switch len(inDir) {
+	case 0:
+		return nil, fmt.Errorf("sticker with name %q not found", token)
+	case 1:
+		return inDir[0], nil
+	}
+
+	names := make([]string, len(inDir))
+	for i, stickerConfig := range inDir {
+		names[i] = stickerConfig.Name
+	}
+
+	slices.Sort(names)
+
+	return nil, fmt.Errorf("%s has stickers %s, choose one by name", token, strings.Join(names, ", "))
+}
@@ -0,0 +1,73 @@
+package stickerUpdater
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"testing"
+)
+
+func TestPreviewFixture(t *testing.T) {
+	fixture, err := LoadFixture("testdata/preview_fixture.json")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if fixture.Pool.PriceUSD != 0.00851 || len(fixture.Candles) != 3 || fixture.Candles[2].Close != 0.00851 {
+		t.Fatalf("Expected the fixture pool and 3 candles, but got %+v", fixture)
+	}
+
+	su, stickerConfig := testAnimationUpdater(t)
+	stickerConfig.Name, stickerConfig.dir = "Anon", "tokens/anon"
+	su.stickers = map[string]*StickerConfig{stickerConfig.Name: stickerConfig}
+
+	data, err := su.Preview(context.Background(), "anon", fixture, PreviewPNG)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Expected a PNG, but got %v", err)
+	}
+
+	if img.Bounds().Dx() != 512 || img.Bounds().Dy() != 512 {
+		t.Errorf("Expected 512x512, but got %v", img.Bounds())
+	}
+
+	if _, err := su.Preview(context.Background(), "anon", fixture, "gif"); err == nil {
+		t.Errorf("Expected error for an unknown format, but got nil")
+	}
+
+	if _, err := su.Preview(context.Background(), "anon", fixture, PreviewWebM); err == nil {
+		t.Errorf("Expected error for a token without animation, but got nil")
+	}
+}
+
+func TestFindSticker(t *testing.T) {
+	su := &StickerUpdater{stickers: map[string]*StickerConfig{
+		"Anon":    {Name: "Anon", dir: "tokens/anon"},
+		"Anon 7d": {Name: "Anon 7d", dir: "tokens/anon"},
+		"Gram":    {Name: "Gram", dir: "tokens/gram"},
+	}}
+
+	tests := map[string]string{"Anon": "Anon", "anon 7d": "Anon 7d", "gram": "Gram"}
+	for token, expected := range tests {
+		stickerConfig, err := su.findSticker(token)
+		if err != nil || stickerConfig.Name != expected {
+			t.Errorf("%s: expected %s, but got %v (%v)", token, expected, stickerConfig, err)
+		}
+	}
+
+	// the directory has two stickers, the name "anon" matches one of them
+	if stickerConfig, err := su.findSticker("anon"); err != nil || stickerConfig.Name != "Anon" {
+		t.Errorf("Expected Anon, but got %v (%v)", stickerConfig, err)
+	}
+
+	delete(su.stickers, "Anon")
+	su.stickers["Anon 1d"] = &StickerConfig{Name: "Anon 1d", dir: "tokens/anon"}
+
+	if _, err := su.findSticker("anon"); err == nil {
+		t.Errorf("Expected error for a directory with several stickers, but got nil")
+	}
+}
@@ -0,0 +1,162 @@
+package stickerUpdater
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ad/anonstickerbot/config"
+)
+
+const (
+	DefaultProvider  = "geckoterminal"
+	DefaultTimeframe = "15m"
+	DefaultCandles   = 24
+	MaxCandles       = 1000
+)
+
+// Timeframes are the supported candle durations.
+var Timeframes = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+	"4h":  4 * time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+// CandleQuery selects the candle series: Limit candles of Timeframe each.
+type CandleQuery struct {
+	Timeframe string
+	Limit     int
+}
+
+// Window holds pool activity for a single time window (5m, 1h, 6h or 24h).
+type Window struct {
+	Volume      float64
+	Buys        int
+	Sells       int
+	PriceChange float64
+}
+
+// PoolSnapshot is a provider-neutral view of a pool state used by the renderer.
+type PoolSnapshot struct {
+	Name    string
+	Address string
+
+	PriceUSD                 float64
+	QuoteTokenPriceUSD       float64
+	BaseTokenPriceQuoteToken float64
+	QuoteTokenPriceBaseToken float64
+
+	FdvUSD     float64
+	ReserveUSD float64
+
+	M5  Window
+	H1  Window
+	H6  Window
+	H24 Window
+}
+
+// CandleSource fetches candle series for a pool, oldest candle first.
+type CandleSource interface {
+	GetCandles(ctx context.Context, address string, query CandleQuery) ([]Candle, error)
+}
+
+// MarketDataProvider fetches pool snapshots and candle series from a market data source.
+type MarketDataProvider interface {
+	CandleSource
+	Name() string
+	GetPool(ctx context.Context, address string) (PoolSnapshot, error)
+}
+
+// CandleDelegate is a provider serving the candles of another provider.
+type CandleDelegate interface {
+	CandleProvider() MarketDataProvider
+}
+
+// PoolFinder finds the pool a token is traded in the most.
+type PoolFinder interface {
+	FindPool(ctx context.Context, tokenAddress string) (string, error)
+}
+
+func initProviders(conf *config.Config) map[string]MarketDataProvider {
+	providers := make(map[string]MarketDataProvider)
+
+	client := &http.Client{Timeout: time.Duration(conf.HTTP_TIMEOUT) * time.Second}
+
+	gecko := NewGeckoterminalProvider(client, conf.DATA_URL, conf.DATA_OHLCV_URL)
+	providers[gecko.Name()] = gecko
+
+	if conf.DEXSCREENER_URL != "" {
+		dexscreener := NewDexscreenerProvider(client, conf.DEXSCREENER_URL, gecko)
+		providers[dexscreener.Name()] = dexscreener
+	}
+
+	return providers
+}
+
+// validProviders checks that every provider of names is registered,
+// dexscreener is registered only with DEXSCREENER_URL.
+func (su *StickerUpdater) validProviders(names ...string) error {
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		if _, ok := su.providers[name]; !ok {
+			return fmt.Errorf("unknown data provider %q", name)
+		}
+	}
+
+	return nil
+}
+
+// providerFor builds the ordered failover chain for a token from its
+// info.json "providers"/"provider" fields or the global DATA_PROVIDER list.
+func (su *StickerUpdater) providerFor(stickerConfig *StickerConfig) (MarketDataProvider, error) {
+	names := stickerConfig.Providers
+	if len(names) == 0 && stickerConfig.Provider != "" {
+		names = []string{stickerConfig.Provider}
+	}
+
+	if len(names) == 0 {
+		names = strings.Split(su.config.DATA_PROVIDER, ",")
+	}
+
+	var providers []MarketDataProvider
+
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		provider, ok := su.providers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown data provider %q", name)
+		}
+
+		providers = append(providers, provider)
+	}
+
+	if len(providers) == 0 {
+		providers = append(providers, su.providers[DefaultProvider])
+	}
+
+	// unknown addresses and commands from users must not put providers of
+	// the tokens on cool-down
+	health := su.health
+
+	switch {
+	case stickerConfig.onDemand:
+		health = su.onDemandHealth
+	case stickerConfig.command:
+		health = su.commandHealth
+	}
+
+	return newFailoverProvider(providers, health), nil
+}
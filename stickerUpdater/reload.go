@@ -0,0 +1,266 @@
+package stickerUpdater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxNoticeLength keeps admin notices below the Telegram message limit.
+const maxNoticeLength = 4000
+
+// tokenFiles are the files of a token directory read by loadToken.
+var tokenFiles = append([]string{"info.json", "sticker.webp", layoutFileName}, logoFiles...)
+
+// pollTokens reloads TOKENS_PATH every TOKENS_RELOAD seconds until ctx is
+// cancelled.
+func (su *StickerUpdater) pollTokens(ctx context.Context) {
+	if su.config.TOKENS_RELOAD <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(su.config.TOKENS_RELOAD) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			su.reloadTokens()
+		}
+	}
+}
+
+// reloadTokens reads TOKENS_PATH again and reports what failed to load.
+func (su *StickerUpdater) reloadTokens() {
+	entries, err := os.ReadDir(su.config.TOKENS_PATH)
+	if err != nil {
+		su.reportLoadErrors([]error{err})
+		return
+	}
+
+	su.reportLoadErrors(su.loadTokens(entries))
+}
+
+// loadTokens brings the stickers in line with the entries of TOKENS_PATH:
+// new token directories are loaded, changed ones are reloaded and missing
+// ones are removed. A directory failing to load keeps its last stickers and
+// its error is returned once until its files change again.
+func (su *StickerUpdater) loadTokens(entries []os.DirEntry) []error {
+	su.reloadMutex.Lock()
+	defer su.reloadMutex.Unlock()
+
+	var errs []error
+
+	found := make(map[string]bool, len(entries))
+	fontDirs := []string{su.config.FONTS_PATH}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || skipTokenDir(entry.Name()) {
+			continue
+		}
+
+		dir := fmt.Sprintf("%s/%s", su.config.TOKENS_PATH, entry.Name())
+		found[dir] = true
+		fontDirs = append(fontDirs, filepath.Join(dir, fontsDirName))
+
+		version := tokenVersion(dir)
+		if previous, ok := su.tokenVersions[dir]; ok && previous == version {
+			continue
+		}
+
+		su.tokenVersions[dir] = version
+
+		if _, err := su.loadTokenDir(dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for dir := range su.tokenVersions {
+		if !found[dir] {
+			delete(su.tokenVersions, dir)
+			su.unloadToken(dir)
+		}
+	}
+
+	// replaced font files are parsed again on the next render
+	if version := fontsVersion(fontDirs); version != su.fontsVersion {
+		su.fontsVersion = version
+		su.fonts.Purge()
+	}
+
+	return errs
+}
+
+// loadTokenDir loads the stickers of the token directory dir in place of the
+// loaded ones, they are updated on the next tick. Without info.json the
+// directory is not a token and its stickers are removed.
+func (su *StickerUpdater) loadTokenDir(dir string) ([]*StickerConfig, error) {
+	stickerConfigs, err := su.loadToken(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		su.unloadToken(dir)
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	su.stateMutex.Lock()
+
+	names := make(map[string]bool, len(stickerConfigs))
+
+	for _, stickerConfig := range stickerConfigs {
+		if loaded, ok := su.stickers[stickerConfig.Name]; ok && loaded.dir != dir {
+			su.stateMutex.Unlock()
+			return nil, fmt.Errorf("%s/info.json: duplicate sticker name %q, it's in %s", dir, stickerConfig.Name, loaded.dir)
+		}
+
+		names[stickerConfig.Name] = true
+	}
+
+	removed := su.dropStickers(func(name string, stickerConfig *StickerConfig) bool {
+		return stickerConfig.dir == dir && !names[name]
+	})
+
+	for _, stickerConfig := range stickerConfigs {
+		su.stickers[stickerConfig.Name] = stickerConfig
+
+		// the old settings are gone with the cached data and the backoff
+		delete(su.snapshots, stickerConfig.Name)
+		delete(su.charts, stickerConfig.Name)
+		delete(su.states, stickerConfig.Name)
+	}
+
+	su.stateMutex.Unlock()
+
+	su.forgetStickers(removed)
+
+	return stickerConfigs, nil
+}
+
+// unloadToken stops updating the stickers of the token directory dir and
+// returns their names.
+func (su *StickerUpdater) unloadToken(dir string) []string {
+	su.stateMutex.Lock()
+	removed := su.dropStickers(func(name string, stickerConfig *StickerConfig) bool {
+		return stickerConfig.dir == dir
+	})
+	su.stateMutex.Unlock()
+
+	su.forgetStickers(removed)
+
+	return removed
+}
+
+// dropStickers deletes the stickers matching drop with their state and
+// returns their sorted names. stateMutex must be held.
+func (su *StickerUpdater) dropStickers(drop func(name string, stickerConfig *StickerConfig) bool) []string {
+	var names []string
+
+	for name, stickerConfig := range su.stickers {
+		if drop(name, stickerConfig) {
+			names = append(names, name)
+			delete(su.stickers, name)
+			delete(su.snapshots, name)
+			delete(su.charts, name)
+			delete(su.paused, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// forgetStickers removes the stickers from inline results.
+func (su *StickerUpdater) forgetStickers(names []string) {
+	if su.sender == nil || len(names) == 0 {
+		return
+	}
+
+	su.sender.Lock()
+	defer su.sender.Unlock()
+
+	for _, name := range names {
+		delete(su.sender.LastStickers, name)
+	}
+}
+
+// tokenVersion identifies the contents of a token directory by the sizes
+// and modification times of its files.
+func tokenVersion(dir string) string {
+	version := new(strings.Builder)
+
+	for _, name := range tokenFiles {
+		info, err := os.Stat(fmt.Sprintf("%s/%s", dir, name))
+		if err != nil {
+			continue
+		}
+
+		fmt.Fprintf(version, "%s:%d:%d;", name, info.Size(), info.ModTime().UnixNano())
+	}
+
+	return version.String()
+}
+
+// fontsVersion identifies the font files in dirs by their sizes and
+// modification times.
+func fontsVersion(dirs []string) string {
+	version := new(strings.Builder)
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			if !slices.Contains(strings.Fields(fontExtensions), strings.ToLower(filepath.Ext(entry.Name()))) {
+				continue
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+
+			fmt.Fprintf(version, "%s:%d:%d;", filepath.Join(dir, entry.Name()), info.Size(), info.ModTime().UnixNano())
+		}
+	}
+
+	return version.String()
+}
+
+// reportLoadErrors logs the errors of token directories and sends them to
+// the admins in one message.
+func (su *StickerUpdater) reportLoadErrors(errs []error) {
+	if len(errs) == 0 {
+		return
+	}
+
+	lines := []string{"Tokens failed to load:"}
+
+	for _, err := range errs {
+		su.logger.Error(fmt.Sprintf("tokens load error: %s", err))
+		lines = append(lines, err.Error())
+	}
+
+	if su.sender == nil {
+		return
+	}
+
+	notice := []rune(strings.Join(lines, "\n"))
+	if len(notice) > maxNoticeLength {
+		notice = append(notice[:maxNoticeLength-1], '…')
+	}
+
+	su.sender.NotifyAdmins(string(notice))
+}
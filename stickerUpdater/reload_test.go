@@ -0,0 +1,174 @@
+package stickerUpdater
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func writeToken(t *testing.T, dir, info string, modified time.Time) {
+	t.Helper()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(dir+"/info.json", []byte(info), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the same size in the same instant isn't a change
+	if err := os.Chtimes(dir+"/info.json", modified, modified); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func reload(t *testing.T, su *StickerUpdater) []error {
+	t.Helper()
+
+	entries, err := os.ReadDir(su.config.TOKENS_PATH)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return su.loadTokens(entries)
+}
+
+func TestLoadTokens(t *testing.T) {
+	su := testAdminUpdater(t)
+	dir := su.config.TOKENS_PATH + "/anon"
+	modified := time.Now()
+
+	writeToken(t, dir, `{"name": "Anon", "address": "pool", "stickers": [{"name": "Anon 1d", "timeframe": "1h"}]}`, modified)
+
+	if errs := reload(t, su); len(errs) != 0 || len(su.stickers) != 2 {
+		t.Fatalf("Expected 2 stickers added, but got %d and %v", len(su.stickers), errs)
+	}
+
+	anon := su.stickers["Anon"]
+	su.states["Anon"] = &tokenState{failures: 3}
+	su.setPaused("Anon", true)
+
+	if errs := reload(t, su); len(errs) != 0 || su.stickers["Anon"] != anon {
+		t.Errorf("Expected no reload of unchanged files, but got %v", errs)
+	}
+
+	modified = modified.Add(time.Second)
+	writeToken(t, dir, `{"name": "Anon", "address": "pool2"}`, modified)
+
+	if errs := reload(t, su); len(errs) != 0 {
+		t.Fatalf("Expected no errors, but got %v", errs)
+	}
+
+	if su.stickers["Anon"].Address != "pool2" || su.stickers["Anon 1d"] != nil {
+		t.Errorf("Expected Anon of pool2 without variants, but got %+v", su.stickers)
+	}
+
+	if _, ok := su.states["Anon"]; ok || !su.paused["Anon"] {
+		t.Errorf("Expected Anon updated anew and still paused")
+	}
+
+	// a broken edit keeps the last stickers and is reported once
+	modified = modified.Add(time.Second)
+	writeToken(t, dir, `{"name": "Anon",`, modified)
+
+	errs := reload(t, su)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), dir+"/info.json") {
+		t.Errorf("Expected the error of %s/info.json, but got %v", dir, errs)
+	}
+
+	if su.stickers["Anon"] == nil {
+		t.Errorf("Expected Anon kept")
+	}
+
+	if errs := reload(t, su); len(errs) != 0 {
+		t.Errorf("Expected the error reported once, but got %v", errs)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if errs := reload(t, su); len(errs) != 0 || len(su.stickers) != 0 || len(su.paused) != 0 {
+		t.Errorf("Expected no stickers left, but got %+v and %v", su.stickers, errs)
+	}
+}
+
+func TestLoadTokensDuplicate(t *testing.T) {
+	su := testAdminUpdater(t)
+	modified := time.Now()
+
+	writeToken(t, su.config.TOKENS_PATH+"/anon", `{"name": "Anon", "address": "pool"}`, modified)
+	writeToken(t, su.config.TOKENS_PATH+"/copy", `{"name": "Anon", "address": "pool2"}`, modified)
+
+	errs := reload(t, su)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "duplicate sticker name") {
+		t.Errorf("Expected a duplicate name error, but got %v", errs)
+	}
+
+	if su.stickers["Anon"].Address != "pool" {
+		t.Errorf("Expected Anon of the first directory, but got %+v", su.stickers["Anon"])
+	}
+}
+
+func TestTokenVersion(t *testing.T) {
+	dir := t.TempDir()
+	modified := time.Now()
+
+	empty := tokenVersion(dir)
+
+	writeToken(t, dir, `{"name": "Anon"}`, modified)
+	written := tokenVersion(dir)
+
+	writeToken(t, dir, `{"name": "Anon"}`, modified.Add(time.Second))
+
+	if written == empty || tokenVersion(dir) == written {
+		t.Errorf("Expected a new version of every change, but got %q", written)
+	}
+}
+
+func TestLoadTokensFonts(t *testing.T) {
+	su := testAdminUpdater(t)
+	dir := su.config.TOKENS_PATH + "/anon"
+	modified := time.Now()
+
+	writeToken(t, dir, `{"name": "Anon", "address": "pool"}`, modified)
+
+	fontPath := dir + "/fonts/Anon.ttf"
+	writeFont := func(modified time.Time) {
+		if err := os.MkdirAll(dir+"/fonts", 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(fontPath, goregular.TTF, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Chtimes(fontPath, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFont(modified)
+	reload(t, su)
+
+	if _, err := su.fonts.font(fontPath); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	reload(t, su)
+
+	if len(su.fonts.fonts) != 1 {
+		t.Errorf("Expected the font kept while unchanged, but got %d", len(su.fonts.fonts))
+	}
+
+	writeFont(modified.Add(time.Second))
+	reload(t, su)
+
+	if len(su.fonts.fonts) != 0 {
+		t.Errorf("Expected the replaced font purged, but got %d", len(su.fonts.fonts))
+	}
+}
@@ -0,0 +1,100 @@
+package stickerUpdater
+
+import (
+	"cmp"
+	"image"
+	"image/draw"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+// render draws the token layout over the sticker template.
+func (su *StickerUpdater) render(stickerConfig *StickerConfig, data PoolSnapshot, candles []Candle) (image.Image, error) {
+	templateFileImage, err := su.renderText(stickerConfig, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return su.drawCharts(templateFileImage, stickerConfig, candles, len(candles))
+}
+
+// renderText draws the text blocks of the layout over the sticker template.
+func (su *StickerUpdater) renderText(stickerConfig *StickerConfig, data PoolSnapshot) (image.Image, error) {
+	dc := gg.NewContextForImage(stickerConfig.image)
+	locale, location := stickerConfig.localization()
+
+	renderData := RenderData{
+		PoolSnapshot: data,
+		Token:        stickerConfig.Name,
+		Emoji:        stickerConfig.Emoji,
+		Now:          su.clock().In(location),
+	}
+
+	fonts := su.fonts.session(stickerConfig.dir)
+	defer fonts.release()
+
+	for i := range stickerConfig.layout.Blocks {
+		block := &stickerConfig.layout.Blocks[i]
+
+		face, err := fonts.chain(stickerConfig.layout.fonts(block), block.FontSize)
+		if err != nil {
+			return nil, err
+		}
+
+		dc.SetFontFace(face)
+
+		if err := block.draw(dc, renderData, stickerConfig.palette(), locale); err != nil {
+			return nil, err
+		}
+	}
+
+	return dc.Image(), nil
+}
+
+// clock is the time rendered stickers show.
+func (su *StickerUpdater) clock() time.Time {
+	if su.now == nil {
+		return time.Now()
+	}
+
+	return su.now()
+}
+
+// drawCharts draws the first visible candles into the chart regions of a copy
+// of background. The chart is scaled to all candles, so it doesn't jump while
+// candles are added one by one.
+func (su *StickerUpdater) drawCharts(background image.Image, stickerConfig *StickerConfig, candles []Candle, visible int) (image.Image, error) {
+	if len(candles) == 0 || len(stickerConfig.layout.Charts) == 0 {
+		return background, nil
+	}
+
+	fonts := su.fonts.session(stickerConfig.dir)
+	defer fonts.release()
+
+	imgNRGBA := image.NewNRGBA(background.Bounds())
+	draw.Draw(imgNRGBA, background.Bounds(), background, image.Point{0, 0}, draw.Over)
+
+	locale, location := stickerConfig.localization()
+
+	for _, region := range stickerConfig.layout.Charts {
+		opts := region.options(stickerConfig.palette(), locale, location)
+		opts.Visible = visible
+		opts.ChartType = stickerConfig.ChartType
+		opts.Volume = stickerConfig.Volume
+		opts.Overlays = stickerConfig.Overlays
+
+		if region.labels() {
+			face, err := fonts.chain(stickerConfig.layout.fonts(&TextBlock{}), cmp.Or(region.LabelSize, defaultLabelSize))
+			if err != nil {
+				return nil, err
+			}
+
+			opts.Axes.Face = face
+		}
+
+		createAxes(imgNRGBA, candles, opts)
+	}
+
+	return imgNRGBA, nil
+}
@@ -0,0 +1,198 @@
+package stickerUpdater
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+const schedulerTick = time.Second
+
+// tokenState is the independent schedule and error state of a single token.
+type tokenState struct {
+	nextRun     time.Time
+	lastRun     time.Time
+	lastSuccess time.Time
+	failures    int
+	lastError   error
+}
+
+func (su *StickerUpdater) Name() string {
+	return "sticker updater"
+}
+
+// Start runs the scheduler in the background until Stop is called.
+func (su *StickerUpdater) Start(ctx context.Context) error {
+	// updates already in progress get their own context, so stopping the
+	// scheduler lets them finish within the shutdown timeout
+	su.runCtx, su.cancelRuns = context.WithCancel(ctx)
+
+	var scheduleCtx context.Context
+	scheduleCtx, su.stopSchedule = context.WithCancel(ctx)
+	su.scheduleDone = make(chan struct{})
+
+	go func() {
+		defer close(su.scheduleDone)
+
+		// a slow token directory must not hold up the updates
+		reloadDone := make(chan struct{})
+		go func() {
+			defer close(reloadDone)
+			su.pollTokens(scheduleCtx)
+		}()
+
+		su.schedule(scheduleCtx)
+		<-reloadDone
+	}()
+
+	return nil
+}
+
+// Stop stops scheduling new updates and waits for the running ones. Updates
+// still running when ctx is done are cancelled.
+func (su *StickerUpdater) Stop(ctx context.Context) error {
+	su.stopSchedule()
+	<-su.scheduleDone
+
+	done := make(chan struct{})
+	go func() {
+		su.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		su.cancelRuns()
+
+		return nil
+	case <-ctx.Done():
+		su.cancelRuns()
+		<-done
+
+		return ctx.Err()
+	}
+}
+
+// schedule runs every token on its own interval until ctx is cancelled.
+// A failing token is retried with exponential backoff without delaying the others.
+func (su *StickerUpdater) schedule(ctx context.Context) {
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+
+	su.runDue(su.runCtx, time.Now())
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			su.runDue(su.runCtx, now)
+		}
+	}
+}
+
+func (su *StickerUpdater) runDue(ctx context.Context, now time.Time) {
+	su.stateMutex.Lock()
+	defer su.stateMutex.Unlock()
+
+	for name, stickerConfig := range su.stickers {
+		state, ok := su.states[name]
+		if !ok {
+			state = &tokenState{nextRun: now}
+			su.states[name] = state
+		}
+
+		if now.Before(state.nextRun) || su.pausedAll || su.paused[name] {
+			continue
+		}
+
+		if _, ok := su.running[name]; ok {
+			continue
+		}
+
+		// don't pick the token again until this run has finished
+		state.nextRun = now.Add(su.nextDelay(stickerConfig, state.failures))
+
+		su.inflight.Add(1)
+		go func(stickerConfig *StickerConfig, state *tokenState) {
+			defer su.inflight.Done()
+			su.runScheduled(ctx, stickerConfig, state)
+		}(stickerConfig, state)
+	}
+
+	for name := range su.states {
+		if _, ok := su.stickers[name]; !ok {
+			delete(su.states, name)
+		}
+	}
+}
+
+func (su *StickerUpdater) runScheduled(ctx context.Context, stickerConfig *StickerConfig, state *tokenState) {
+	started := time.Now()
+
+	ran, err := su.run(ctx, stickerConfig)
+	if !ran {
+		return
+	}
+
+	su.stateMutex.Lock()
+	defer su.stateMutex.Unlock()
+
+	state.lastRun = started
+
+	if err != nil {
+		state.failures++
+		state.lastError = err
+
+		su.logger.Error(fmt.Sprintf("%s update failed (%d in a row): %s", stickerConfig.Name, state.failures, err))
+	} else {
+		state.failures = 0
+		state.lastError = nil
+		state.lastSuccess = time.Now()
+	}
+
+	state.nextRun = time.Now().Add(su.nextDelay(stickerConfig, state.failures))
+
+	if su.config.Debug {
+		fmt.Printf("%s next update at %s\n", stickerConfig.Name, state.nextRun.Format(time.RFC3339))
+	}
+}
+
+// interval is the update interval of the sticker without backoff and jitter.
+func (su *StickerUpdater) interval(stickerConfig *StickerConfig) time.Duration {
+	interval := time.Duration(su.config.UPDATE_DELAY) * time.Second
+	if stickerConfig.Interval > 0 {
+		interval = time.Duration(stickerConfig.Interval) * time.Second
+	}
+
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	return interval
+}
+
+// nextDelay returns the token interval, doubled for every consecutive failure
+// up to UPDATE_MAX_BACKOFF, with UPDATE_JITTER percent of random jitter.
+func (su *StickerUpdater) nextDelay(stickerConfig *StickerConfig, failures int) time.Duration {
+	interval := su.interval(stickerConfig)
+	delay := interval
+
+	if failures > 0 {
+		delay = time.Duration(float64(interval) * math.Pow(2, float64(min(failures, 16))))
+
+		maxBackoff := time.Duration(su.config.UPDATE_MAX_BACKOFF) * time.Second
+		if maxBackoff > 0 && delay > maxBackoff {
+			delay = max(maxBackoff, interval)
+		}
+	}
+
+	if su.config.UPDATE_JITTER > 0 {
+		jitter := float64(delay) * float64(su.config.UPDATE_JITTER) / 100
+		delay += time.Duration((rand.Float64()*2 - 1) * jitter)
+	}
+
+	return delay
+}
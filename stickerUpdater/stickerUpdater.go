@@ -2,389 +2,486 @@ package stickerUpdater
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
-	"image/color"
-	"image/draw"
 	"log/slog"
-	"math"
 	"os"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ad/anonstickerbot/config"
 	"github.com/ad/anonstickerbot/sender"
 
-	"github.com/dustin/go-humanize"
-	"github.com/fogleman/gg"
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
-	"github.com/golang/freetype/truetype"
-	"github.com/nickalie/go-webpbin"
-	"golang.org/x/image/font/gofont/goregular"
 	"golang.org/x/image/webp"
 )
 
 type StickerUpdater struct {
-	logger   *slog.Logger
-	config   *config.Config
-	sender   *sender.Sender
-	bot      *bot.Bot
-	stickers map[string]*StickerConfig
+	logger         *slog.Logger
+	config         *config.Config
+	sender         *sender.Sender
+	bot            *bot.Bot
+	providers      map[string]MarketDataProvider
+	health         *HealthTracker
+	fonts          *FontCache
+	stickers       map[string]*StickerConfig
+	generic        *StickerConfig   // template of stickers rendered on demand
+	onDemandHealth *HealthTracker   // health of providers fetching addresses from users
+	commandHealth  *HealthTracker   // health of providers fetching /chart candles
+	now            func() time.Time // the clock of rendered stickers, time.Now when nil
+
+	stateMutex sync.Mutex
+	states     map[string]*tokenState
+	snapshots  map[string]tokenSnapshot
+	charts     map[string]map[string]tokenSnapshot // /chart candles by sticker and timeframe
+	chartUsers map[int64][]time.Time               // uncached /chart fetches of a user within CHART_RATE_WINDOW
+	paused     map[string]bool
+	pausedAll  bool
+	running    map[string]struct{}
+	workers    chan struct{}
+
+	reloadMutex   sync.Mutex        // serializes reloads of TOKENS_PATH with the admin commands
+	tokenVersions map[string]string // versions of the token directories by path, see tokenVersion
+	fontsVersion  string            // version of FONTS_PATH and the fonts of the tokens, see fontsVersion
+
+	runCtx       context.Context
+	cancelRuns   context.CancelFunc
+	stopSchedule context.CancelFunc
+	scheduleDone chan struct{}
+	inflight     sync.WaitGroup
 }
 
 type StickerConfig struct {
-	Name    string      `json:"name"`
-	Address string      `json:"address"`
-	Emoji   string      `json:"emoji"`
-	image   image.Image `json:"-"`
+	Name      string            `json:"name"`
+	Address   string            `json:"address"`
+	Emoji     string            `json:"emoji"`
+	Aliases   []string          `json:"aliases"` // matched by inline queries
+	Provider  string            `json:"provider"`
+	Providers []string          `json:"providers"`
+	Interval  int               `json:"interval"`
+	Animation string            `json:"animation"`
+	ChartType string            `json:"chart_type"`
+	Volume    *VolumePanel      `json:"volume"`
+	Overlays  []Overlay         `json:"overlays"`
+	Timeframe string            `json:"timeframe"`
+	Candles   int               `json:"candles"`
+	Theme     string            `json:"theme"`
+	Locale    string            `json:"locale"`
+	Timezone  string            `json:"timezone"`
+	Stickers  []json.RawMessage `json:"stickers"` // more stickers of the token with their own names and overridden settings
+	image     image.Image       `json:"-"`
+	theme     *Theme            `json:"-"`
+	locale    *Locale           `json:"-"`
+	location  *time.Location    `json:"-"`
+	layout    *Layout           `json:"-"`
+	dir       string            `json:"-"`
+	onDemand  bool              `json:"-"`
+	command   bool              `json:"-"`
 }
 
 func InitStickerUpdater(logger *slog.Logger, config *config.Config, bot *bot.Bot, sender *sender.Sender) (*StickerUpdater, error) {
 	stickerUpdater := &StickerUpdater{
-		logger:   logger,
-		config:   config,
-		bot:      bot,
-		sender:   sender,
-		stickers: make(map[string]*StickerConfig),
+		logger:         logger,
+		config:         config,
+		bot:            bot,
+		sender:         sender,
+		providers:      initProviders(config),
+		health:         NewHealthTracker(time.Duration(config.PROVIDER_COOLDOWN) * time.Second),
+		onDemandHealth: NewHealthTracker(time.Duration(config.PROVIDER_COOLDOWN) * time.Second),
+		commandHealth:  NewHealthTracker(time.Duration(config.PROVIDER_COOLDOWN) * time.Second),
+		fonts:          NewFontCache(config.FONTS_PATH),
+		now:            time.Now,
+		stickers:       make(map[string]*StickerConfig),
+		states:         make(map[string]*tokenState),
+		snapshots:      make(map[string]tokenSnapshot),
+		charts:         make(map[string]map[string]tokenSnapshot),
+		chartUsers:     make(map[int64][]time.Time),
+		paused:         make(map[string]bool),
+		running:        make(map[string]struct{}),
+		workers:        make(chan struct{}, max(config.UPDATE_CONCURRENCY, 1)),
+		tokenVersions:  make(map[string]string),
 	}
 
+	if err := stickerUpdater.validProviders(strings.Split(config.DATA_PROVIDER, ",")...); err != nil {
+		return nil, fmt.Errorf("DATA_PROVIDER: %w", err)
+	}
+
+	if err := validTheme(config.THEME); err != nil {
+		return nil, fmt.Errorf("THEME: %w", err)
+	}
+
+	// the locale of the target chat is the default of its stickers
+	defaultLocale := cmp.Or(config.ChatLocales[config.TelegramTargetChatID], config.LOCALE)
+	if err := validLocale(defaultLocale); err != nil {
+		return nil, fmt.Errorf("LOCALE: %w", err)
+	}
+
+	defaultLocation, err := time.LoadLocation(config.TIMEZONE)
+	if err != nil {
+		return nil, fmt.Errorf("TIMEZONE: %w", err)
+	}
+
+	// TOKENS_PATH/layout.json replaces the built-in layout for every token
+	defaultLayout, err := loadLayout(config.TOKENS_PATH, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s/%s: %w", config.TOKENS_PATH, layoutFileName, err)
+	}
+
+	stickerUpdater.generic = &StickerConfig{
+		layout:   defaultLayout,
+		theme:    Themes[config.THEME],
+		locale:   Locales[defaultLocale],
+		location: defaultLocation,
+		onDemand: true,
+	}
+	stickerUpdater.generic.image = generateBackground(stickerUpdater.generic.theme, nil)
+
 	// read directory with tokens and load configs from json
 	dirs, err := os.ReadDir(config.TOKENS_PATH)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, dir := range dirs {
-		if !dir.IsDir() {
-			continue
+	stickerUpdater.reportLoadErrors(stickerUpdater.loadTokens(dirs))
+
+	fmt.Printf("stickerUpdater.stickers: %d\n", len(stickerUpdater.stickers))
+
+	return stickerUpdater, nil
+}
+
+// skipTokenDir tells whether a directory of TOKENS_PATH is not a token.
+func skipTokenDir(name string) bool {
+	return strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".")
+}
+
+// loadToken reads the stickers of the token directory dir. The error wraps
+// fs.ErrNotExist when there is no info.json.
+func (su *StickerUpdater) loadToken(dir string) ([]*StickerConfig, error) {
+	file, err := os.ReadFile(dir + "/info.json")
+	if err != nil {
+		return nil, err
+	}
+
+	stickerConfigs, err := parseStickerConfigs(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s/info.json: %w", dir, err)
+	}
+
+	for _, stickerConfig := range stickerConfigs {
+		if err := su.validProviders(append([]string{stickerConfig.Provider}, stickerConfig.Providers...)...); err != nil {
+			return nil, fmt.Errorf("%s/info.json: %s: %w", dir, stickerConfig.Name, err)
 		}
 
-		if strings.HasPrefix(dir.Name(), "_") || strings.HasPrefix(dir.Name(), ".") {
-			continue
+		// warned on load rather than failing to encode on every update
+		if stickerConfig.Animation != "" && !binAvailable(su.config.FFMPEG_PATH) {
+			su.logger.Warn(fmt.Sprintf("%s: %s: %q not found, the animation is off", dir, stickerConfig.Name, su.config.FFMPEG_PATH))
+			stickerConfig.Animation = ""
 		}
+	}
+
+	// without sticker.webp the template is generated from the logo
+	var inputFile, logo image.Image
 
-		file, err := os.ReadFile(fmt.Sprintf("%s/%s/info.json", config.TOKENS_PATH, dir.Name()))
+	webpFile, err := os.ReadFile(dir + "/sticker.webp")
+	switch {
+	case err == nil:
+		inputFile, err = webp.Decode(bytes.NewReader(webpFile))
 		if err != nil {
-			continue
+			return nil, fmt.Errorf("%s/sticker.webp: %w", dir, err)
 		}
+	case os.IsNotExist(err):
+		logo, err = loadLogo(dir, su.config.SVG_CONVERT_PATH)
+		switch {
+		case errors.Is(err, errSVGConvert):
+			su.logger.Warn(fmt.Sprintf("%s: %s, the template is generated without the logo", dir, err))
+		case err != nil:
+			return nil, fmt.Errorf("%s: %w", dir, err)
+		}
+	default:
+		return nil, fmt.Errorf("%s/sticker.webp: %w", dir, err)
+	}
+
+	layout, err := loadLayout(dir, su.generic.layout)
+	if err != nil {
+		return nil, fmt.Errorf("%s/%s: %w", dir, layoutFileName, err)
+	}
+
+	for _, stickerConfig := range stickerConfigs {
+		stickerConfig.dir = dir
+		stickerConfig.layout = layout
 
-		stickerConfig := &StickerConfig{}
-		if err := json.Unmarshal(file, stickerConfig); err != nil {
-			continue
+		stickerConfig.theme = su.generic.theme
+		if stickerConfig.Theme != "" {
+			stickerConfig.theme = Themes[stickerConfig.Theme]
 		}
 
-		webpFile, err := os.ReadFile(fmt.Sprintf("%s/%s/sticker.webp", config.TOKENS_PATH, dir.Name()))
-		if err != nil {
-			continue
+		stickerConfig.locale = su.generic.locale
+		if stickerConfig.Locale != "" {
+			stickerConfig.locale = Locales[stickerConfig.Locale]
 		}
 
-		inputFile, err := webp.Decode(bytes.NewReader(webpFile))
-		if err != nil {
-			continue
+		stickerConfig.location = su.generic.location
+		if stickerConfig.Timezone != "" {
+			// validated by initChart
+			stickerConfig.location, _ = time.LoadLocation(stickerConfig.Timezone)
 		}
 
 		stickerConfig.image = inputFile
+		if stickerConfig.image == nil {
+			stickerConfig.image = generateBackground(stickerConfig.theme, logo)
+		}
+	}
+
+	return stickerConfigs, nil
+}
 
-		stickerUpdater.stickers[stickerConfig.Name] = stickerConfig
+// parseStickerConfigs parses info.json of a token into the token sticker and
+// its additional stickers.
+func parseStickerConfigs(file []byte) ([]*StickerConfig, error) {
+	stickerConfig := &StickerConfig{}
+	if err := json.Unmarshal(file, stickerConfig); err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("stickerUpdater.stickers: %d\n", len(stickerUpdater.stickers))
+	variants := stickerConfig.Stickers
+	stickerConfig.Stickers = nil
 
-	return stickerUpdater, nil
+	stickerConfigs := []*StickerConfig{stickerConfig}
+	names := map[string]bool{stickerConfig.Name: true}
+
+	for i, variant := range variants {
+		// settings missing in the variant are taken from the token
+		variantConfig := &StickerConfig{}
+		if err := json.Unmarshal(file, variantConfig); err != nil {
+			return nil, err
+		}
+
+		variantConfig.Stickers = nil
+		if err := json.Unmarshal(variant, variantConfig); err != nil {
+			return nil, fmt.Errorf("stickers[%d]: %w", i, err)
+		}
+
+		if variantConfig.Name == stickerConfig.Name || names[variantConfig.Name] {
+			return nil, fmt.Errorf("stickers[%d]: name %q is not unique", i, variantConfig.Name)
+		}
+
+		names[variantConfig.Name] = true
+		stickerConfigs = append(stickerConfigs, variantConfig)
+	}
+
+	for _, stickerConfig := range stickerConfigs {
+		if err := stickerConfig.initChart(); err != nil {
+			return nil, fmt.Errorf("%s: %w", stickerConfig.Name, err)
+		}
+	}
+
+	return stickerConfigs, nil
+}
+
+// candleQuery is the candle series drawn on the sticker.
+func (stickerConfig *StickerConfig) candleQuery() CandleQuery {
+	return CandleQuery{
+		Timeframe: cmp.Or(stickerConfig.Timeframe, DefaultTimeframe),
+		Limit:     cmp.Or(stickerConfig.Candles, DefaultCandles),
+	}
 }
 
-func (su *StickerUpdater) Run(name string) error {
+func (su *StickerUpdater) Run(ctx context.Context, name string) error {
+	su.stateMutex.Lock()
 	stickerConfig, ok := su.stickers[name]
+	su.stateMutex.Unlock()
+
 	if !ok {
 		return fmt.Errorf("sticker with name %q not found", name)
 	}
 
-	return su.updateSticker(stickerConfig)
-}
-
-func (su *StickerUpdater) RunAll() error {
-	for _, stickerConfig := range su.stickers {
-		if err := su.updateSticker(stickerConfig); err != nil {
-			return err
-		}
+	ran, err := su.run(ctx, stickerConfig)
+	if err == nil && !ran {
+		return fmt.Errorf("sticker with name %q is already updating", name)
 	}
 
-	return nil
+	return err
 }
 
-func (su *StickerUpdater) updateSticker(stickerConfig *StickerConfig) error {
-	dataURL := strings.Replace(su.config.DATA_URL, "%s", stickerConfig.Address, 1)
+// fetchData gets the pool snapshot and candles of the token, candles are
+// optional and nil when they can't be fetched.
+func (su *StickerUpdater) fetchData(ctx context.Context, stickerConfig *StickerConfig) (PoolSnapshot, []Candle, error) {
+	provider, err := su.providerFor(stickerConfig)
+	if err != nil {
+		return PoolSnapshot{}, nil, fmt.Errorf("%s:%s %w", stickerConfig.Name, stickerConfig.Address, err)
+	}
 
-	data, err := getData(dataURL)
+	data, err := provider.GetPool(ctx, stickerConfig.Address)
 	if err != nil {
-		return fmt.Errorf("%s:%s getData error: %w (%s)", stickerConfig.Name, stickerConfig.Address, err, dataURL)
+		return PoolSnapshot{}, nil, fmt.Errorf("%s:%s %s getData error: %w", stickerConfig.Name, stickerConfig.Address, provider.Name(), err)
 	}
 
 	if su.config.Debug {
 		fmt.Println("-------------------------------------")
+		fmt.Printf("Name: %s (%s)\n", data.Name, provider.Name())
+		fmt.Printf("Base token price USD: %f\n", data.PriceUSD)
+		fmt.Printf("Quote token price USD: %f\n", data.QuoteTokenPriceUSD)
+		fmt.Printf("Base token price quote token: %f\n", data.BaseTokenPriceQuoteToken)
+		fmt.Printf("Quote token price base token: %f\n", data.QuoteTokenPriceBaseToken)
+		fmt.Printf("Price change percentage M5: %.2f%%, volume %.0f, buy %d/sell %d\n", data.M5.PriceChange, data.M5.Volume, data.M5.Buys, data.M5.Sells)
+		fmt.Printf("Price change percentage H1: %.2f%%, volume %.0f, buy %d/sell %d\n", data.H1.PriceChange, data.H1.Volume, data.H1.Buys, data.H1.Sells)
+		fmt.Printf("Price change percentage H24: %.2f%%, volume %.0f, buy %d/sell %d\n", data.H24.PriceChange, data.H24.Volume, data.H24.Buys, data.H24.Sells)
+		fmt.Printf("Reserve in USD: %f\n", data.ReserveUSD)
 	}
 
-	m5Volume := int64(0)
-	m5VolumeFloat, err := strconv.ParseFloat(data.Data.Attributes.VolumeUsd.M5, 64)
-	if err == nil {
-		m5Volume = int64(m5VolumeFloat)
+	candles, err := provider.GetCandles(ctx, stickerConfig.Address, stickerConfig.candleQuery())
+	if err != nil {
+		su.logger.Debug(fmt.Sprintf("%s:%s %s getCandles error: %s", stickerConfig.Name, stickerConfig.Address, provider.Name(), err))
 	}
 
-	h1Volume := int64(0)
-	h1VolumeFloat, err := strconv.ParseFloat(data.Data.Attributes.VolumeUsd.H1, 64)
-	if err == nil {
-		h1Volume = int64(h1VolumeFloat)
-	}
+	return data, candles, nil
+}
+
+// tokenSnapshot is the market data of the last update of a sticker.
+type tokenSnapshot struct {
+	data    PoolSnapshot
+	candles []Candle
+	fetched time.Time
+}
 
-	h24Volume := int64(0)
-	h24VolumeFloat, err := strconv.ParseFloat(data.Data.Attributes.VolumeUsd.H24, 64)
-	if err == nil {
-		h24Volume = int64(h24VolumeFloat)
+// snapshot returns the data of the last update when it's not older than the
+// sticker interval and fetches it otherwise.
+func (su *StickerUpdater) snapshot(ctx context.Context, stickerConfig *StickerConfig) (PoolSnapshot, []Candle, error) {
+	su.stateMutex.Lock()
+	cached, ok := su.snapshots[stickerConfig.Name]
+	su.stateMutex.Unlock()
+
+	if ok && time.Since(cached.fetched) < su.interval(stickerConfig) {
+		return cached.data, cached.candles, nil
 	}
 
-	m5PricePercentage, err := strconv.ParseFloat(data.Data.Attributes.PriceChangePercentage.M5, 64)
+	data, candles, err := su.fetchData(ctx, stickerConfig)
 	if err != nil {
-		m5PricePercentage = 0
+		return PoolSnapshot{}, nil, err
 	}
 
-	h1PricePercentage, err := strconv.ParseFloat(data.Data.Attributes.PriceChangePercentage.H1, 64)
+	su.storeSnapshot(stickerConfig, data, candles)
+
+	return data, candles, nil
+}
+
+func (su *StickerUpdater) storeSnapshot(stickerConfig *StickerConfig, data PoolSnapshot, candles []Candle) {
+	su.stateMutex.Lock()
+	defer su.stateMutex.Unlock()
+
+	su.snapshots[stickerConfig.Name] = tokenSnapshot{data: data, candles: candles, fetched: time.Now()}
+}
+
+func (su *StickerUpdater) updateSticker(ctx context.Context, stickerConfig *StickerConfig) error {
+	data, candles, err := su.fetchData(ctx, stickerConfig)
 	if err != nil {
-		h1PricePercentage = 0
+		return err
 	}
 
-	h24PricePercentage, err := strconv.ParseFloat(data.Data.Attributes.PriceChangePercentage.H24, 64)
-	if err != nil {
-		h24PricePercentage = 0
+	su.storeSnapshot(stickerConfig, data, candles)
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%s:%s render cancelled: %w", stickerConfig.Name, stickerConfig.Address, err)
 	}
 
-	if su.config.Debug {
-		fmt.Printf("Name: %s\n", data.Data.Attributes.Name)
-		fmt.Printf("Base token price USD: %s\n", data.Data.Attributes.BaseTokenPriceUsd)
-		fmt.Printf("Quote token price USD: %s\n", data.Data.Attributes.QuoteTokenPriceUsd)
-		fmt.Printf("Base token price quote token: %s\n", data.Data.Attributes.BaseTokenPriceQuoteToken)
-		fmt.Printf("Quote token price base token: %s\n", data.Data.Attributes.QuoteTokenPriceBaseToken)
-		fmt.Printf("Price change percentage M5: %.2f%%, volume %d, buy %d/sell %d\n", m5PricePercentage, m5Volume, data.Data.Attributes.Transactions.M5.Buys, data.Data.Attributes.Transactions.M5.Sells)
-		fmt.Printf("Price change percentage H1: %.2f%%, volume %d, buy %d/sell %d\n", h1PricePercentage, h1Volume, data.Data.Attributes.Transactions.H1.Buys, data.Data.Attributes.Transactions.H1.Sells)
-		// fmt.Printf("Price change percentage H6: %.2f%%, volume %.2f \n", data.Data.Attributes.PriceChangePercentage.H6, data.Data.Attributes.VolumeUsd.H6)
-		fmt.Printf("Price change percentage H24: %.2f%%, volume %d, buy %d/sell %d\n", h24PricePercentage, h24Volume, data.Data.Attributes.Transactions.H24.Buys, data.Data.Attributes.Transactions.H24.Sells)
-		fmt.Printf("Reserve in USD: %s\n", data.Data.Attributes.ReserveInUsd)
-	}
-
-	dc := gg.NewContextForImage(stickerConfig.image)
-
-	font, _ := truetype.Parse(goregular.TTF)
-	face18 := truetype.NewFace(font, &truetype.Options{Size: 18})
-	face24 := truetype.NewFace(font, &truetype.Options{Size: 24})
-	face26 := truetype.NewFace(font, &truetype.Options{Size: 26})
-	face32 := truetype.NewFace(font, &truetype.Options{Size: 32})
-
-	dc.SetRGB(1, 1, 1)
-	dc.SetFontFace(face32)
-	dc.DrawString(data.Data.Attributes.Name, 70, 58)
-
-	baseTokenPriceUsd, err := strconv.ParseFloat(data.Data.Attributes.BaseTokenPriceUsd, 64)
+	stickerData, filename, err := su.encodeSticker(ctx, stickerConfig, data, candles)
 	if err != nil {
-		baseTokenPriceUsd = 0
+		return err
 	}
 
-	quoteTokenPriceBaseToken, err := strconv.ParseFloat(data.Data.Attributes.QuoteTokenPriceBaseToken, 64)
-	if err != nil {
-		quoteTokenPriceBaseToken = 0
+	if su.config.Debug {
+		fmt.Println("-------------------------------------")
 	}
 
-	baseTokenPriceQuoteToken, err := strconv.ParseFloat(data.Data.Attributes.BaseTokenPriceQuoteToken, 64)
-	if err != nil {
-		baseTokenPriceQuoteToken = 0
-	}
-
-	dc.SetFontFace(face24)
-	dc.DrawStringAnchored(
-		fmt.Sprintf(
-			"$%s   A%s   T%s",
-			humanize.CommafWithDigits(baseTokenPriceUsd, 5),
-			humanize.CommafWithDigits(quoteTokenPriceBaseToken, 2),
-			humanize.CommafWithDigits(baseTokenPriceQuoteToken, 5),
-		),
-		256, 280, 0.5, 0)
-
-	dc.SetFontFace(face26)
-
-	dc.DrawStringWrapped(
-		fmt.Sprintf(
-			"5M\n$%s\n%s/%s",
-			humanize.Comma(m5Volume),
-			humanize.Comma(int64(data.Data.Attributes.Transactions.M5.Buys)),
-			humanize.Comma(int64(data.Data.Attributes.Transactions.M5.Sells)),
-		),
-		24,
-		140,
-		0,
-		0,
-		150,
-		1.25,
-		gg.AlignLeft,
-	)
-
-	m5PricePercentageColor := color.RGBA{128, 128, 128, 255}
-	if m5PricePercentage > 0 {
-		m5PricePercentageColor = color.RGBA{126, 211, 33, 255}
-	} else if m5PricePercentage < 0 {
-		m5PricePercentageColor = color.RGBA{208, 2, 27, 255}
-	}
-
-	dc.SetColor(m5PricePercentageColor)
-
-	dc.DrawStringAnchored(
-		fmt.Sprintf("%.2f%%", math.Abs(m5PricePercentage)),
-		65,
-		166,
-		0,
-		0,
-	)
-
-	dc.SetRGB(1, 1, 1)
-
-	dc.DrawStringWrapped(
-		fmt.Sprintf(
-			"1H\n$%s\n%s/%s",
-			humanize.Comma(h1Volume),
-			humanize.Comma(int64(data.Data.Attributes.Transactions.H1.Buys)),
-			humanize.Comma(int64(data.Data.Attributes.Transactions.H1.Sells)),
-		),
-		184,
-		140,
-		0,
-		0,
-		150,
-		1.25,
-		gg.AlignLeft,
-	)
-
-	h1PricePercentageColor := color.RGBA{128, 128, 128, 255}
-	if h1PricePercentage > 0 {
-		h1PricePercentageColor = color.RGBA{126, 211, 33, 255}
-	} else if h1PricePercentage < 0 {
-		h1PricePercentageColor = color.RGBA{208, 2, 27, 255}
-	}
-
-	dc.SetColor(h1PricePercentageColor)
-
-	dc.DrawStringAnchored(
-		fmt.Sprintf("%.2f%%", math.Abs(h1PricePercentage)),
-		222,
-		166,
-		0,
-		0,
-	)
-
-	dc.SetRGB(1, 1, 1)
-
-	dc.DrawStringWrapped(
-		fmt.Sprintf(
-			"24H\n$%s\n%s/%s",
-			humanize.Comma(h24Volume),
-			humanize.Comma(int64(data.Data.Attributes.Transactions.H24.Buys)),
-			humanize.Comma(int64(data.Data.Attributes.Transactions.H24.Sells)),
-		),
-		334,
-		140,
-		0,
-		0,
-		150,
-		1.25,
-		gg.AlignLeft,
-	)
-
-	h24PricePercentageColor := color.RGBA{128, 128, 128, 255}
-	if h24PricePercentage > 0 {
-		h24PricePercentageColor = color.RGBA{126, 211, 33, 255}
-	} else if h24PricePercentage < 0 {
-		h24PricePercentageColor = color.RGBA{208, 2, 27, 255}
-	}
-
-	dc.SetColor(h24PricePercentageColor)
-	dc.DrawStringAnchored(
-		fmt.Sprintf("%.2f%%", math.Abs(h24PricePercentage)),
-		385,
-		166,
-		0,
-		0,
-	)
-
-	dc.SetRGB(1, 1, 1)
-
-	dc.SetFontFace(face18)
-	dc.DrawStringAnchored(time.Now().Format(time.RFC822), 490, 100, 1, 0.5)
-
-	mCap, err := strconv.ParseInt(data.Data.Attributes.FdvUsd, 10, 64)
+	inlineSticker, err := su.uploadSticker(ctx, stickerConfig, data, stickerData, filename)
 	if err != nil {
-		mCap = 0
+		return err
 	}
 
-	dc.SetFontFace(face26)
-	dc.DrawStringAnchored("$"+humanize.Comma(mCap), 490, 30, 1, 1)
+	su.stateMutex.Lock()
+	removed := su.stickers[stickerConfig.Name] != stickerConfig
+	su.stateMutex.Unlock()
 
-	templateFileImage := dc.Image()
-	if su.config.DATA_OHLCV_URL != "" {
-		dataOhlcvURL := strings.Replace(su.config.DATA_OHLCV_URL, "%s", stickerConfig.Address, 1)
-		ohlcvData, err := getOHLCVData(dataOhlcvURL)
-		if err == nil {
-			candles := getCandles(ohlcvData)
-			if len(candles) > 0 {
-				imgNRGBA := image.NewNRGBA(image.Rect(0, 0, 512, 512))
-				draw.Draw(imgNRGBA, templateFileImage.Bounds(), templateFileImage, image.Point{0, 0}, draw.Over)
-
-				createAxes(
-					imgNRGBA,
-					candles,
-					Options{
-						YOffset:     300,
-						Width:       512,
-						Height:      512,
-						CandleWidth: 6,
-						Rows:        20,
-						Columns:     20,
-					},
-				)
-
-				templateFileImage = imgNRGBA
-			}
-		}
+	// removed or reloaded while updating
+	if removed {
+		return nil
 	}
 
-	buf := new(bytes.Buffer)
+	su.sender.Lock()
+	defer su.sender.Unlock()
 
-	if err := webpbin.Encode(buf, templateFileImage); err != nil {
-		return err
-	}
+	su.sender.LastStickers[stickerConfig.Name] = inlineSticker
 
-	if su.config.Debug {
-		fmt.Println("-------------------------------------")
-	}
+	return nil
+}
+
+// uploadSticker sends the sticker to the target chat, inline results reuse
+// the file uploaded there.
+func (su *StickerUpdater) uploadSticker(ctx context.Context, stickerConfig *StickerConfig, data PoolSnapshot, stickerData []byte, filename string) (sender.InlineSticker, error) {
+	sendCtx, cancel := config.WithTimeout(ctx, su.config.TELEGRAM_TIMEOUT)
+	defer cancel()
 
-	msg, err := su.bot.SendSticker(context.Background(), &bot.SendStickerParams{
+	msg, err := su.bot.SendSticker(sendCtx, &bot.SendStickerParams{
 		ChatID:         su.config.TelegramTargetChatID,
 		ProtectContent: false,
 		Emoji:          stickerConfig.Emoji,
 		Sticker: &models.InputFileUpload{
-			Filename: "sticker.webp",
-			Data:     bytes.NewReader(buf.Bytes()),
+			Filename: filename,
+			Data:     bytes.NewReader(stickerData),
 		},
 	})
 
 	if err != nil {
 		fmt.Printf("err: %+v\n", err)
-		return err
+		return sender.InlineSticker{}, err
 	}
 
-	su.sender.Lock()
-	defer su.sender.Unlock()
+	symbol, _, _ := strings.Cut(data.Name, " / ")
 
-	su.sender.LastStickers[stickerConfig.Name] = msg.Sticker.FileID
+	return sender.InlineSticker{
+		Name:    stickerConfig.Name,
+		FileID:  msg.Sticker.FileID,
+		Symbol:  strings.TrimSpace(symbol),
+		Address: stickerConfig.Address,
+		Aliases: stickerConfig.Aliases,
+	}, nil
+}
 
-	return nil
+// encodeSticker renders the sticker as a video when the token is animated and
+// as a static image otherwise or when the video can't be encoded.
+func (su *StickerUpdater) encodeSticker(ctx context.Context, stickerConfig *StickerConfig, data PoolSnapshot, candles []Candle) ([]byte, string, error) {
+	if stickerConfig.Animation != "" {
+		video, err := su.animatedSticker(ctx, stickerConfig, data, candles)
+		if err == nil {
+			return video, "sticker.webm", nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, "", fmt.Errorf("%s:%s animation cancelled: %w", stickerConfig.Name, stickerConfig.Address, ctx.Err())
+		}
+
+		su.logger.Error(fmt.Sprintf("%s:%s animation error, sending static sticker: %s", stickerConfig.Name, stickerConfig.Address, err))
+	}
+
+	templateFileImage, err := su.render(stickerConfig, data, candles)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s:%s render error: %w", stickerConfig.Name, stickerConfig.Address, err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, "", fmt.Errorf("%s:%s encode cancelled: %w", stickerConfig.Name, stickerConfig.Address, err)
+	}
+
+	stickerData, err := encodeWebP(ctx, su.config.WEBP_ENCODER, templateFileImage)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s:%s encode error: %w", stickerConfig.Name, stickerConfig.Address, err)
+	}
+
+	return stickerData, "sticker.webp", nil
 }
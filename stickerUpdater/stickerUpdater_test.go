@@ -0,0 +1,92 @@
+package stickerUpdater
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ad/anonstickerbot/config"
+)
+
+func TestParseStickerConfigs(t *testing.T) {
+	file := []byte(`{
+		"name": "Anon",
+		"address": "addr",
+		"chart_type": "line",
+		"stickers": [
+			{"name": "Anon 6h", "timeframe": "15m", "candles": 24},
+			{"name": "Anon 7d", "timeframe": "4h", "candles": 42, "chart_type": "area"}
+		]
+	}`)
+
+	stickerConfigs, err := parseStickerConfigs(file)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if len(stickerConfigs) != 3 {
+		t.Fatalf("Expected 3 stickers, but got %d", len(stickerConfigs))
+	}
+
+	if query := stickerConfigs[0].candleQuery(); query != (CandleQuery{Timeframe: DefaultTimeframe, Limit: DefaultCandles}) {
+		t.Errorf("Expected the default query, but got %+v", query)
+	}
+
+	week := stickerConfigs[2]
+	if week.Name != "Anon 7d" || week.Address != "addr" || week.ChartType != ChartArea || week.Stickers != nil {
+		t.Errorf("Expected Anon 7d area chart of addr, but got %+v", week)
+	}
+
+	if query := week.candleQuery(); query != (CandleQuery{Timeframe: "4h", Limit: 42}) {
+		t.Errorf("Expected 42 4h candles, but got %+v", query)
+	}
+
+	if stickerConfigs[1].ChartType != ChartLine {
+		t.Errorf("Expected the line chart of the token, but got %q", stickerConfigs[1].ChartType)
+	}
+}
+
+func TestParseStickerConfigsErrors(t *testing.T) {
+	files := []string{
+		`{"name": "Anon", "stickers": [{"timeframe": "1h"}]}`,
+		`{"name": "Anon", "stickers": [{"name": "Anon 1h"}, {"name": "Anon 1h"}]}`,
+		`{"name": "Anon", "timeframe": "2h"}`,
+		`{"name": "Anon", "candles": 5000}`,
+	}
+
+	for _, file := range files {
+		if _, err := parseStickerConfigs([]byte(file)); err == nil {
+			t.Errorf("Expected an error for %s", file)
+		}
+	}
+}
+
+func TestValidProviders(t *testing.T) {
+	conf := &config.Config{
+		TOKENS_PATH:   t.TempDir(),
+		DATA_PROVIDER: "geckoterminal, dexscreener",
+		THEME:         "dark",
+		LOCALE:        "en",
+		TIMEZONE:      "UTC",
+	}
+
+	// dexscreener is there with its URL only
+	if _, err := InitStickerUpdater(slog.New(slog.DiscardHandler), conf, nil, nil); err == nil {
+		t.Errorf("Expected an error for dexscreener without DEXSCREENER_URL")
+	}
+
+	conf.DEXSCREENER_URL = "http://localhost/%s"
+
+	su, err := InitStickerUpdater(slog.New(slog.DiscardHandler), conf, nil, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	dir := conf.TOKENS_PATH + "/anon"
+	writeToken(t, dir, `{"name": "Anon", "address": "pool", "stickers": [{"name": "Anon 1d", "providers": ["dexscreener", "coingecko"]}]}`, time.Now())
+
+	if _, err := su.loadToken(dir); err == nil || !strings.Contains(err.Error(), `Anon 1d: unknown data provider "coingecko"`) {
+		t.Errorf("Expected an unknown provider error of Anon 1d, but got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package stickerUpdater
+
+import (
+	"fmt"
+	"image/color"
+)
+
+const DefaultTheme = "dark"
+
+// Theme is the palette text and charts are drawn with. Layout colors may
+// refer to it by name: "text", "positive", "negative", "neutral", "wick" and
+// "background".
+type Theme struct {
+	Text       color.NRGBA
+	Positive   color.NRGBA
+	Negative   color.NRGBA
+	Neutral    color.NRGBA
+	Wick       color.NRGBA
+	Background color.NRGBA
+}
+
+// Themes are the palettes selectable with THEME or the token theme.
+var Themes = map[string]*Theme{
+	"dark": {
+		Text:       color.NRGBA{R: 255, G: 255, B: 255, A: 255},
+		Positive:   color.NRGBA{R: 126, G: 211, B: 33, A: 255},
+		Negative:   color.NRGBA{R: 208, G: 2, B: 27, A: 255},
+		Neutral:    color.NRGBA{R: 128, G: 128, B: 128, A: 255},
+		Wick:       color.NRGBA{R: 211, G: 211, B: 211, A: 255},
+		Background: color.NRGBA{R: 18, G: 18, B: 18, A: 255},
+	},
+	"light": {
+		Text:       color.NRGBA{R: 33, G: 33, B: 33, A: 255},
+		Positive:   color.NRGBA{R: 38, G: 166, B: 91, A: 255},
+		Negative:   color.NRGBA{R: 214, G: 48, B: 49, A: 255},
+		Neutral:    color.NRGBA{R: 117, G: 117, B: 117, A: 255},
+		Wick:       color.NRGBA{R: 97, G: 97, B: 97, A: 255},
+		Background: color.NRGBA{R: 250, G: 250, B: 250, A: 255},
+	},
+	"high-contrast": {
+		Text:       color.NRGBA{R: 255, G: 255, B: 255, A: 255},
+		Positive:   color.NRGBA{R: 0, G: 255, B: 0, A: 255},
+		Negative:   color.NRGBA{R: 255, G: 0, B: 0, A: 255},
+		Neutral:    color.NRGBA{R: 255, G: 255, B: 0, A: 255},
+		Wick:       color.NRGBA{R: 255, G: 255, B: 255, A: 255},
+		Background: color.NRGBA{A: 255},
+	},
+	// blue and orange of the Okabe-Ito palette, distinguishable with any
+	// kind of color blindness
+	"colorblind": {
+		Text:       color.NRGBA{R: 255, G: 255, B: 255, A: 255},
+		Positive:   color.NRGBA{R: 0, G: 114, B: 178, A: 255},
+		Negative:   color.NRGBA{R: 230, G: 159, B: 0, A: 255},
+		Neutral:    color.NRGBA{R: 153, G: 153, B: 153, A: 255},
+		Wick:       color.NRGBA{R: 211, G: 211, B: 211, A: 255},
+		Background: color.NRGBA{R: 18, G: 18, B: 18, A: 255},
+	},
+}
+
+// palette returns the theme of the token, the default one before it's loaded.
+func (stickerConfig *StickerConfig) palette() *Theme {
+	if stickerConfig.theme == nil {
+		return Themes[DefaultTheme]
+	}
+
+	return stickerConfig.theme
+}
+
+// color resolves a layout color: a theme color name or a hex color, empty is
+// the text color.
+func (theme *Theme) color(value string) (color.Color, error) {
+	switch value {
+	case "", "text":
+		return theme.Text, nil
+	case "positive":
+		return theme.Positive, nil
+	case "negative":
+		return theme.Negative, nil
+	case "neutral":
+		return theme.Neutral, nil
+	case "wick":
+		return theme.Wick, nil
+	case "background":
+		return theme.Background, nil
+	}
+
+	return parseHexColor(value)
+}
+
+// candleColor is positive for rising, negative for falling and neutral for
+// unchanged prices.
+func (theme *Theme) candleColor(open, close float64) color.NRGBA {
+	switch {
+	case open > close:
+		return theme.Negative
+	case open < close:
+		return theme.Positive
+	}
+
+	return theme.Neutral
+}
+
+// faded returns the text color with alpha, for gridlines and labels.
+func (theme *Theme) faded(alpha uint8) color.NRGBA {
+	faded := theme.Text
+	faded.A = alpha
+
+	return faded
+}
+
+func validTheme(name string) error {
+	if _, ok := Themes[name]; !ok {
+		return fmt.Errorf("unknown theme %q", name)
+	}
+
+	return nil
+}
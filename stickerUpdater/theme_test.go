@@ -0,0 +1,68 @@
+package stickerUpdater
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestThemeColor(t *testing.T) {
+	theme := Themes["light"]
+
+	tests := map[string]color.Color{
+		"":         theme.Text,
+		"text":     theme.Text,
+		"positive": theme.Positive,
+		"wick":     theme.Wick,
+		"#fff":     color.NRGBA{R: 255, G: 255, B: 255, A: 255},
+	}
+
+	for value, expected := range tests {
+		result, err := theme.color(value)
+		if err != nil {
+			t.Errorf("%q: expected no error, but got %v", value, err)
+			continue
+		}
+
+		if result != expected {
+			t.Errorf("%q: expected %v, but got %v", value, expected, result)
+		}
+	}
+
+	if _, err := theme.color("purple"); err == nil {
+		t.Errorf("Expected error for unknown color, but got nil")
+	}
+}
+
+func TestThemeCandleColors(t *testing.T) {
+	theme := Themes["colorblind"]
+	img := image.NewNRGBA(image.Rect(0, 0, 512, 212))
+
+	createAxes(img, testCandles(24), Options{Width: 512, Height: 212, CandleWidth: 6, Columns: 20, Theme: theme})
+
+	positive, other := 0, 0
+	for y := 0; y < 212; y++ {
+		for x := 0; x < 512; x++ {
+			switch pixel := img.NRGBAAt(x, y); {
+			case pixel == theme.Positive:
+				positive++
+			case pixel.A != 0 && pixel != theme.Wick:
+				other++
+			}
+		}
+	}
+
+	if positive == 0 || other != 0 {
+		t.Errorf("Expected rising candles in the positive color only, but got %d positive and %d other pixels", positive, other)
+	}
+}
+
+func TestInitChartTheme(t *testing.T) {
+	if err := (&StickerConfig{Theme: "high-contrast"}).initChart(); err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+
+	if err := (&StickerConfig{Theme: "sepia"}).initChart(); err == nil {
+		t.Errorf("Expected error for unknown theme, but got nil")
+	}
+}
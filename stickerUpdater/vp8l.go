@@ -0,0 +1,828 @@
+package stickerUpdater
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"math/bits"
+	"slices"
+)
+
+// This file is a lossless WebP (VP8L) encoder. It applies the subtract green
+// and predictor transforms and LZ77 backward references with a single set of
+// prefix codes, no color cache and no meta prefix codes.
+// The format is described at https://developers.google.com/speed/webp/docs/webp_lossless_bitstream_specification
+
+const (
+	vp8lMagic        = 0x2f
+	vp8lMaxDimension = 1 << 14
+
+	vp8lPredictorBits = 4 // predictor modes are chosen per 16x16 tile
+
+	vp8lLiteralCodes  = 256
+	vp8lLengthCodes   = 24
+	vp8lDistanceCodes = 40
+
+	vp8lMaxCodeLength           = 15
+	vp8lMaxCodeLengthCodeLength = 7
+
+	vp8lMinMatch    = 3
+	vp8lMaxMatch    = 4096
+	vp8lMaxDistance = 1<<20 - 120
+	vp8lHashBits    = 16
+	vp8lMaxChain    = 32
+)
+
+var vp8lCodeLengthCodeOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// vp8lDistanceMap maps short two-dimensional distances to distance codes 1-120,
+// as (y << 4) | (8 - x).
+var vp8lDistanceMap = [120]uint8{
+	0x18, 0x07, 0x17, 0x19, 0x28, 0x06, 0x27, 0x29, 0x16, 0x1a,
+	0x26, 0x2a, 0x38, 0x05, 0x37, 0x39, 0x15, 0x1b, 0x36, 0x3a,
+	0x25, 0x2b, 0x48, 0x04, 0x47, 0x49, 0x14, 0x1c, 0x35, 0x3b,
+	0x46, 0x4a, 0x24, 0x2c, 0x58, 0x45, 0x4b, 0x34, 0x3c, 0x03,
+	0x57, 0x59, 0x13, 0x1d, 0x56, 0x5a, 0x23, 0x2d, 0x44, 0x4c,
+	0x55, 0x5b, 0x33, 0x3d, 0x68, 0x02, 0x67, 0x69, 0x12, 0x1e,
+	0x66, 0x6a, 0x22, 0x2e, 0x54, 0x5c, 0x43, 0x4d, 0x65, 0x6b,
+	0x32, 0x3e, 0x78, 0x01, 0x77, 0x79, 0x53, 0x5d, 0x11, 0x1f,
+	0x64, 0x6c, 0x42, 0x4e, 0x76, 0x7a, 0x21, 0x2f, 0x75, 0x7b,
+	0x31, 0x3f, 0x63, 0x6d, 0x52, 0x5e, 0x00, 0x74, 0x7c, 0x41,
+	0x4f, 0x10, 0x20, 0x62, 0x6e, 0x30, 0x73, 0x7d, 0x51, 0x5f,
+	0x40, 0x72, 0x7e, 0x61, 0x6f, 0x50, 0x71, 0x7f, 0x60, 0x70,
+}
+
+// encodeLossless encodes img as a lossless WebP. With quantize > 0 the low
+// quantize bits of every channel are rounded off first, which trades exact
+// colors for a smaller file.
+func encodeLossless(img image.Image, quantize int) ([]byte, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width < 1 || height < 1 || width > vp8lMaxDimension || height > vp8lMaxDimension {
+		return nil, errors.New("vp8l: invalid image size")
+	}
+
+	argb, hasAlpha := toARGB(img, quantize)
+
+	bw := &bitWriter{}
+	bw.write(vp8lMagic, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	bw.write(boolBit(hasAlpha), 1)
+	bw.write(0, 3) // version
+
+	// subtract green transform
+	bw.write(1, 1)
+	bw.write(2, 2)
+	subtractGreen(argb)
+
+	// predictor transform
+	bw.write(1, 1)
+	bw.write(0, 2)
+	bw.write(vp8lPredictorBits-2, 3)
+
+	tilesWidth := tiles(width, vp8lPredictorBits)
+	modes := choosePredictors(argb, width, height)
+	writeImageData(bw, modes, tilesWidth, false)
+
+	residuals := applyPredictors(argb, width, height, modes)
+
+	bw.write(0, 1) // no more transforms
+	writeImageData(bw, residuals, width, true)
+
+	return riffVP8L(bw.bytes()), nil
+}
+
+func riffVP8L(data []byte) []byte {
+	padding := len(data) & 1
+
+	out := make([]byte, 0, 20+len(data)+padding)
+	out = append(out, "RIFF"...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(4+8+len(data)+padding))
+	out = append(out, "WEBPVP8L"...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(data)))
+	out = append(out, data...)
+
+	if padding == 1 {
+		out = append(out, 0)
+	}
+
+	return out
+}
+
+// toARGB converts img into ARGB pixels. Colors of fully transparent pixels
+// are dropped, they are not visible but cost space.
+func toARGB(img image.Image, quantize int) ([]uint32, bool) {
+	bounds := img.Bounds()
+
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok || nrgba.Rect.Min != (image.Point{}) {
+		nrgba = image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+		draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+	}
+
+	argb := make([]uint32, bounds.Dx()*bounds.Dy())
+	hasAlpha := false
+
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			p := nrgba.Pix[y*nrgba.Stride+x*4:]
+
+			r, g, b, a := quantizeChannel(p[0], quantize), quantizeChannel(p[1], quantize), quantizeChannel(p[2], quantize), quantizeChannel(p[3], quantize)
+			if a == 0 {
+				r, g, b = 0, 0, 0
+			}
+			if a != 0xff {
+				hasAlpha = true
+			}
+
+			argb[y*bounds.Dx()+x] = uint32(a)<<24 | uint32(r)<<16 | uint32(g)<<8 | uint32(b)
+		}
+	}
+
+	return argb, hasAlpha
+}
+
+func quantizeChannel(value uint8, quantize int) uint8 {
+	// fully opaque stays opaque
+	if quantize <= 0 || value == 0xff {
+		return value
+	}
+
+	return uint8(min(int(value)+1<<(quantize-1), 0xff) &^ (1<<quantize - 1))
+}
+
+func subtractGreen(argb []uint32) {
+	for i, p := range argb {
+		green := (p >> 8) & 0xff
+		red := ((p >> 16) - green) & 0xff
+		blue := (p - green) & 0xff
+
+		argb[i] = p&0xff00ff00 | red<<16 | blue
+	}
+}
+
+func tiles(size, tileBits int) int {
+	return (size + 1<<tileBits - 1) >> tileBits
+}
+
+// choosePredictors picks for every tile the predictor mode with the smallest
+// residuals. The modes are stored in the green channel of the tile image.
+func choosePredictors(argb []uint32, width, height int) []uint32 {
+	tilesWidth, tilesHeight := tiles(width, vp8lPredictorBits), tiles(height, vp8lPredictorBits)
+	modes := make([]uint32, tilesWidth*tilesHeight)
+
+	for ty := 0; ty < tilesHeight; ty++ {
+		for tx := 0; tx < tilesWidth; tx++ {
+			bestMode, bestCost := uint32(0), -1
+
+			for mode := uint32(0); mode < 14; mode++ {
+				cost := 0
+
+				for y := ty << vp8lPredictorBits; y < min((ty+1)<<vp8lPredictorBits, height); y++ {
+					for x := tx << vp8lPredictorBits; x < min((tx+1)<<vp8lPredictorBits, width); x++ {
+						// the first row and column have fixed predictors
+						if x == 0 || y == 0 {
+							continue
+						}
+
+						i := y*width + x
+						cost += residualCost(subPixels(argb[i], predict(mode, argb, i, width)))
+					}
+				}
+
+				if bestCost < 0 || cost < bestCost {
+					bestMode, bestCost = mode, cost
+				}
+			}
+
+			modes[ty*tilesWidth+tx] = 0xff000000 | bestMode<<8
+		}
+	}
+
+	return modes
+}
+
+func applyPredictors(argb []uint32, width, height int, modes []uint32) []uint32 {
+	tilesWidth := tiles(width, vp8lPredictorBits)
+	residuals := make([]uint32, len(argb))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+
+			var prediction uint32
+
+			switch {
+			case x == 0 && y == 0:
+				prediction = 0xff000000
+			case y == 0:
+				prediction = argb[i-1]
+			case x == 0:
+				prediction = argb[i-width]
+			default:
+				mode := (modes[(y>>vp8lPredictorBits)*tilesWidth+x>>vp8lPredictorBits] >> 8) & 0x0f
+				prediction = predict(mode, argb, i, width)
+			}
+
+			residuals[i] = subPixels(argb[i], prediction)
+		}
+	}
+
+	return residuals
+}
+
+// predict returns the prediction of mode for pixel i, which is neither in the
+// first row nor in the first column.
+func predict(mode uint32, argb []uint32, i, width int) uint32 {
+	left, top, topLeft, topRight := argb[i-1], argb[i-width], argb[i-width-1], argb[i-width+1]
+
+	switch mode {
+	case 0:
+		return 0xff000000
+	case 1:
+		return left
+	case 2:
+		return top
+	case 3:
+		return topRight
+	case 4:
+		return topLeft
+	case 5:
+		return average2(average2(left, topRight), top)
+	case 6:
+		return average2(left, topLeft)
+	case 7:
+		return average2(left, top)
+	case 8:
+		return average2(topLeft, top)
+	case 9:
+		return average2(top, topRight)
+	case 10:
+		return average2(average2(left, topLeft), average2(top, topRight))
+	case 11:
+		return selectPredictor(left, top, topLeft)
+	case 12:
+		return perChannel(left, top, topLeft, func(a, b, c int) int { return a + b - c })
+	case 13:
+		return perChannel(average2(left, top), topLeft, 0, func(a, b, _ int) int { return a + (a-b)/2 })
+	}
+
+	return 0
+}
+
+func channel(p uint32, shift int) int {
+	return int((p >> shift) & 0xff)
+}
+
+func average2(a, b uint32) uint32 {
+	return perChannel(a, b, 0, func(a, b, _ int) int { return (a + b) / 2 })
+}
+
+func perChannel(a, b, c uint32, f func(a, b, c int) int) uint32 {
+	var p uint32
+
+	for shift := 0; shift < 32; shift += 8 {
+		value := min(max(f(channel(a, shift), channel(b, shift), channel(c, shift)), 0), 0xff)
+		p |= uint32(value) << shift
+	}
+
+	return p
+}
+
+func selectPredictor(left, top, topLeft uint32) uint32 {
+	var leftCost, topCost int
+
+	for shift := 0; shift < 32; shift += 8 {
+		leftCost += abs(channel(topLeft, shift) - channel(top, shift))
+		topCost += abs(channel(topLeft, shift) - channel(left, shift))
+	}
+
+	if leftCost < topCost {
+		return left
+	}
+
+	return top
+}
+
+func subPixels(a, b uint32) uint32 {
+	var p uint32
+
+	for shift := 0; shift < 32; shift += 8 {
+		p |= uint32((channel(a, shift)-channel(b, shift))&0xff) << shift
+	}
+
+	return p
+}
+
+func residualCost(p uint32) int {
+	cost := 0
+
+	for shift := 0; shift < 32; shift += 8 {
+		cost += abs(int(int8(channel(p, shift))))
+	}
+
+	return cost
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
+
+// vp8lToken is a literal pixel or, when length > 0, a backward reference.
+type vp8lToken struct {
+	argb     uint32
+	length   int
+	distance int // distance code, not the distance in pixels
+}
+
+// writeImageData writes an entropy coded image: the main image when topLevel
+// is set, a transform sub-image otherwise.
+func writeImageData(bw *bitWriter, argb []uint32, width int, topLevel bool) {
+	bw.write(0, 1) // no color cache
+	if topLevel {
+		bw.write(0, 1) // no meta prefix codes
+	}
+
+	tokens := backwardReferences(argb, width)
+
+	green := make([]int, vp8lLiteralCodes+vp8lLengthCodes)
+	red := make([]int, vp8lLiteralCodes)
+	blue := make([]int, vp8lLiteralCodes)
+	alpha := make([]int, vp8lLiteralCodes)
+	distance := make([]int, vp8lDistanceCodes)
+
+	for _, token := range tokens {
+		if token.length > 0 {
+			lengthCode, _, _ := prefixEncode(token.length)
+			distanceCode, _, _ := prefixEncode(token.distance)
+
+			green[vp8lLiteralCodes+lengthCode]++
+			distance[distanceCode]++
+
+			continue
+		}
+
+		green[(token.argb>>8)&0xff]++
+		red[(token.argb>>16)&0xff]++
+		blue[token.argb&0xff]++
+		alpha[token.argb>>24]++
+	}
+
+	codes := [5]prefixCode{
+		writePrefixCode(bw, huffmanLengths(green, vp8lMaxCodeLength)),
+		writePrefixCode(bw, huffmanLengths(red, vp8lMaxCodeLength)),
+		writePrefixCode(bw, huffmanLengths(blue, vp8lMaxCodeLength)),
+		writePrefixCode(bw, huffmanLengths(alpha, vp8lMaxCodeLength)),
+		writePrefixCode(bw, huffmanLengths(distance, vp8lMaxCodeLength)),
+	}
+
+	for _, token := range tokens {
+		if token.length > 0 {
+			lengthCode, lengthBits, lengthExtra := prefixEncode(token.length)
+			codes[0].write(bw, vp8lLiteralCodes+lengthCode)
+			bw.write(lengthExtra, lengthBits)
+
+			distanceCode, distanceBits, distanceExtra := prefixEncode(token.distance)
+			codes[4].write(bw, distanceCode)
+			bw.write(distanceExtra, distanceBits)
+
+			continue
+		}
+
+		codes[0].write(bw, int((token.argb>>8)&0xff))
+		codes[1].write(bw, int((token.argb>>16)&0xff))
+		codes[2].write(bw, int(token.argb&0xff))
+		codes[3].write(bw, int(token.argb>>24))
+	}
+}
+
+// prefixEncode splits a length or distance code into its prefix symbol and
+// extra bits.
+func prefixEncode(value int) (int, uint, uint32) {
+	value--
+	if value < 4 {
+		return value, 0, 0
+	}
+
+	highest := bits.Len(uint(value)) - 1
+	second := (value >> (highest - 1)) & 1
+	extraBits := highest - 1
+
+	return 2*highest + second, uint(extraBits), uint32(value & (1<<extraBits - 1))
+}
+
+// backwardReferences greedily replaces repeated pixel runs with references,
+// checking the previous pixel, the pixel above and a hash chain of earlier
+// positions with the same two pixels.
+func backwardReferences(argb []uint32, width int) []vp8lToken {
+	n := len(argb)
+	distanceCodes := vp8lDistanceCodes2D(width)
+
+	head := make([]int32, 1<<vp8lHashBits)
+	for i := range head {
+		head[i] = -1
+	}
+	chain := make([]int32, n)
+
+	hash := func(i int) uint32 {
+		return (argb[i]*0x1e35a7bd ^ argb[i+1]*0x9e3779b1) >> (32 - vp8lHashBits)
+	}
+	insert := func(i int) {
+		if i+1 < n {
+			h := hash(i)
+			chain[i] = head[h]
+			head[h] = int32(i)
+		}
+	}
+
+	tokens := make([]vp8lToken, 0, n/4)
+
+	for i := 0; i < n; {
+		maxLength := min(vp8lMaxMatch, n-i)
+		bestLength, bestPosition := 0, 0
+
+		try := func(j int) {
+			if j < 0 || i-j > vp8lMaxDistance || bestLength == maxLength {
+				return
+			}
+
+			length := 0
+			for length < maxLength && argb[j+length] == argb[i+length] {
+				length++
+			}
+
+			if length > bestLength {
+				bestLength, bestPosition = length, j
+			}
+		}
+
+		try(i - 1)
+		try(i - width)
+
+		if i+1 < n {
+			for j, steps := head[hash(i)], 0; j >= 0 && steps < vp8lMaxChain; j, steps = chain[j], steps+1 {
+				try(int(j))
+			}
+		}
+
+		if bestLength < vp8lMinMatch {
+			tokens = append(tokens, vp8lToken{argb: argb[i]})
+			insert(i)
+			i++
+
+			continue
+		}
+
+		distance := i - bestPosition
+		code, ok := distanceCodes[distance]
+		if !ok {
+			code = distance + len(vp8lDistanceMap)
+		}
+
+		tokens = append(tokens, vp8lToken{length: bestLength, distance: code})
+
+		for k := 0; k < bestLength; k++ {
+			insert(i + k)
+		}
+		i += bestLength
+	}
+
+	return tokens
+}
+
+// vp8lDistanceCodes2D maps distances in pixels to the short distance codes.
+func vp8lDistanceCodes2D(width int) map[int]int {
+	codes := make(map[int]int, len(vp8lDistanceMap))
+
+	for i, value := range vp8lDistanceMap {
+		distance := int(value>>4)*width + 8 - int(value&0xf)
+		if _, ok := codes[distance]; !ok && distance >= 1 {
+			codes[distance] = i + 1
+		}
+	}
+
+	return codes
+}
+
+// huffmanLengths returns code lengths for the histogram limited to limit bits.
+// Rare symbols are made more frequent until the tree is shallow enough.
+func huffmanLengths(histogram []int, limit int) []uint8 {
+	lengths := make([]uint8, len(histogram))
+
+	var symbols []int
+	for symbol, count := range histogram {
+		if count > 0 {
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	switch len(symbols) {
+	case 0:
+		return lengths
+	case 1:
+		lengths[symbols[0]] = 1
+		return lengths
+	}
+
+	for floor := 1; ; floor *= 2 {
+		if buildHuffman(histogram, symbols, floor, lengths) <= limit {
+			return lengths
+		}
+	}
+}
+
+// buildHuffman writes the code lengths of symbols into lengths and returns the
+// longest one.
+func buildHuffman(histogram []int, symbols []int, floor int, lengths []uint8) int {
+	type node struct {
+		weight int
+		parent int
+		symbol int
+	}
+
+	nodes := make([]node, len(symbols), 2*len(symbols)-1)
+	for i, symbol := range symbols {
+		nodes[i] = node{weight: max(histogram[symbol], floor), symbol: symbol}
+	}
+
+	// leaves sorted by weight, internal nodes are created in weight order,
+	// so the two smallest nodes are always at the front of one of the queues
+	leaves := nodes[:len(symbols)]
+	slices.SortFunc(leaves, func(a, b node) int {
+		return cmp.Or(cmp.Compare(a.weight, b.weight), cmp.Compare(a.symbol, b.symbol))
+	})
+
+	leaf, internal := 0, len(symbols)
+	smallest := func() int {
+		if leaf < len(symbols) && (internal >= len(nodes) || nodes[leaf].weight <= nodes[internal].weight) {
+			leaf++
+			return leaf - 1
+		}
+
+		internal++
+
+		return internal - 1
+	}
+
+	for len(nodes) < cap(nodes) {
+		a, b := smallest(), smallest()
+		nodes = append(nodes, node{weight: nodes[a].weight + nodes[b].weight, symbol: -1})
+		nodes[a].parent = len(nodes) - 1
+		nodes[b].parent = len(nodes) - 1
+	}
+
+	depths := make([]int, len(nodes))
+	longest := 0
+
+	for i := len(nodes) - 2; i >= 0; i-- {
+		depths[i] = depths[nodes[i].parent] + 1
+
+		if nodes[i].symbol >= 0 {
+			lengths[nodes[i].symbol] = uint8(depths[i])
+			longest = max(longest, depths[i])
+		}
+	}
+
+	return longest
+}
+
+// prefixCode holds canonical codes, bit reversed for the LSB first writer.
+type prefixCode struct {
+	codes   []uint32
+	lengths []uint8
+}
+
+func (pc prefixCode) write(bw *bitWriter, symbol int) {
+	bw.write(pc.codes[symbol], uint(pc.lengths[symbol]))
+}
+
+// writePrefixCode writes the code lengths and returns the code to write the
+// symbols with. A code with a single symbol takes no bits at all.
+func writePrefixCode(bw *bitWriter, lengths []uint8) prefixCode {
+	var symbols []int
+	for symbol, length := range lengths {
+		if length > 0 {
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	pc := prefixCode{codes: make([]uint32, len(lengths)), lengths: make([]uint8, len(lengths))}
+
+	if len(symbols) <= 2 && (len(symbols) == 0 || symbols[len(symbols)-1] < 256) {
+		writeSimplePrefixCode(bw, symbols)
+
+		if len(symbols) == 2 {
+			pc.codes[symbols[1]] = 1
+			pc.lengths[symbols[0]] = 1
+			pc.lengths[symbols[1]] = 1
+		}
+
+		return pc
+	}
+
+	bw.write(0, 1) // normal code
+	writeCodeLengths(bw, lengths)
+
+	if len(symbols) == 1 {
+		return pc
+	}
+
+	copy(pc.lengths, lengths)
+	pc.codes = canonicalCodes(lengths)
+
+	return pc
+}
+
+func writeSimplePrefixCode(bw *bitWriter, symbols []int) {
+	bw.write(1, 1) // simple code
+
+	first := 0
+	if len(symbols) > 0 {
+		first = symbols[0]
+	}
+
+	bw.write(uint32(max(len(symbols), 1)-1), 1)
+
+	if first < 2 {
+		bw.write(0, 1)
+		bw.write(uint32(first), 1)
+	} else {
+		bw.write(1, 1)
+		bw.write(uint32(first), 8)
+	}
+
+	if len(symbols) == 2 {
+		bw.write(uint32(symbols[1]), 8)
+	}
+}
+
+// writeCodeLengths writes code lengths with the code length code, using
+// 16 for repeats of the previous length and 17 and 18 for runs of zeros.
+func writeCodeLengths(bw *bitWriter, lengths []uint8) {
+	type lengthToken struct {
+		symbol    int
+		extra     uint32
+		extraBits uint
+	}
+
+	var tokens []lengthToken
+
+	previous := uint8(8)
+
+	for i := 0; i < len(lengths); {
+		length := lengths[i]
+
+		run := 1
+		for i+run < len(lengths) && lengths[i+run] == length {
+			run++
+		}
+		i += run
+
+		if length == 0 {
+			for run >= 11 {
+				repeat := min(run, 138)
+				tokens = append(tokens, lengthToken{symbol: 18, extra: uint32(repeat - 11), extraBits: 7})
+				run -= repeat
+			}
+			if run >= 3 {
+				tokens = append(tokens, lengthToken{symbol: 17, extra: uint32(run - 3), extraBits: 3})
+				run = 0
+			}
+			for ; run > 0; run-- {
+				tokens = append(tokens, lengthToken{symbol: 0})
+			}
+
+			continue
+		}
+
+		if length != previous {
+			tokens = append(tokens, lengthToken{symbol: int(length)})
+			previous = length
+			run--
+		}
+		for run >= 3 {
+			repeat := min(run, 6)
+			tokens = append(tokens, lengthToken{symbol: 16, extra: uint32(repeat - 3), extraBits: 2})
+			run -= repeat
+		}
+		for ; run > 0; run-- {
+			tokens = append(tokens, lengthToken{symbol: int(length)})
+		}
+	}
+
+	histogram := make([]int, len(vp8lCodeLengthCodeOrder))
+	for _, token := range tokens {
+		histogram[token.symbol]++
+	}
+
+	codeLengthLengths := huffmanLengths(histogram, vp8lMaxCodeLengthCodeLength)
+
+	count := len(vp8lCodeLengthCodeOrder)
+	for count > 4 && codeLengthLengths[vp8lCodeLengthCodeOrder[count-1]] == 0 {
+		count--
+	}
+
+	bw.write(uint32(count-4), 4)
+	for _, symbol := range vp8lCodeLengthCodeOrder[:count] {
+		bw.write(uint32(codeLengthLengths[symbol]), 3)
+	}
+
+	bw.write(0, 1) // all code lengths are written
+
+	var codeLengthCode prefixCode
+
+	switch used := nonZero(codeLengthLengths); {
+	case used > 1:
+		codeLengthCode = prefixCode{codes: canonicalCodes(codeLengthLengths), lengths: codeLengthLengths}
+	default:
+		codeLengthCode = prefixCode{codes: make([]uint32, len(codeLengthLengths)), lengths: make([]uint8, len(codeLengthLengths))}
+	}
+
+	for _, token := range tokens {
+		codeLengthCode.write(bw, token.symbol)
+		bw.write(token.extra, token.extraBits)
+	}
+}
+
+func nonZero(lengths []uint8) int {
+	count := 0
+	for _, length := range lengths {
+		if length > 0 {
+			count++
+		}
+	}
+
+	return count
+}
+
+// canonicalCodes assigns canonical codes to the lengths, bit reversed.
+func canonicalCodes(lengths []uint8) []uint32 {
+	var counts [vp8lMaxCodeLength + 1]uint32
+	for _, length := range lengths {
+		counts[length]++
+	}
+	counts[0] = 0
+
+	var next [vp8lMaxCodeLength + 1]uint32
+
+	code := uint32(0)
+	for length := 1; length <= vp8lMaxCodeLength; length++ {
+		code = (code + counts[length-1]) << 1
+		next[length] = code
+	}
+
+	codes := make([]uint32, len(lengths))
+	for symbol, length := range lengths {
+		if length == 0 {
+			continue
+		}
+
+		codes[symbol] = uint32(bits.Reverse32(next[length]) >> (32 - length))
+		next[length]++
+	}
+
+	return codes
+}
+
+func boolBit(value bool) uint32 {
+	if value {
+		return 1
+	}
+
+	return 0
+}
+
+// bitWriter writes bits least significant first.
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nBits uint
+}
+
+func (bw *bitWriter) write(value uint32, n uint) {
+	bw.acc |= uint64(value) << bw.nBits
+	bw.nBits += n
+
+	for bw.nBits >= 8 {
+		bw.buf = append(bw.buf, byte(bw.acc))
+		bw.acc >>= 8
+		bw.nBits -= 8
+	}
+}
+
+func (bw *bitWriter) bytes() []byte {
+	if bw.nBits > 0 {
+		bw.buf = append(bw.buf, byte(bw.acc))
+		bw.acc, bw.nBits = 0, 0
+	}
+
+	return bw.buf
+}
@@ -0,0 +1,85 @@
+package stickerUpdater
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func testImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	random := rand.New(rand.NewSource(1))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			switch {
+			case y < height/3:
+				// gradient
+				img.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y * 2), B: uint8(x + y), A: 0xff})
+			case y < 2*height/3:
+				// noise with transparency
+				img.SetNRGBA(x, y, color.NRGBA{R: uint8(random.Intn(256)), G: uint8(random.Intn(256)), B: uint8(random.Intn(256)), A: uint8(random.Intn(256))})
+			default:
+				// flat stripes
+				img.SetNRGBA(x, y, color.NRGBA{R: uint8(x / 16 * 40), G: 0x80, B: 0x20, A: 0xff})
+			}
+		}
+	}
+
+	return img
+}
+
+func TestEncodeLosslessRoundTrip(t *testing.T) {
+	for _, size := range []image.Point{{1, 1}, {3, 2}, {17, 33}, {100, 60}, {512, 512}} {
+		img := testImage(size.X, size.Y)
+
+		data, err := encodeLossless(img, 0)
+		if err != nil {
+			t.Fatalf("%v: expected no error, but got %v", size, err)
+		}
+
+		decoded, err := webp.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%v: expected no decode error, but got %v", size, err)
+		}
+
+		for y := 0; y < size.Y; y++ {
+			for x := 0; x < size.X; x++ {
+				expected := img.NRGBAAt(x, y)
+				if expected.A == 0 {
+					expected = color.NRGBA{}
+				}
+
+				if got := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA); got != expected {
+					t.Fatalf("%v: expected %v at %d,%d, but got %v", size, expected, x, y, got)
+				}
+			}
+		}
+	}
+}
+
+func TestEncodeLosslessQuantize(t *testing.T) {
+	img := testImage(256, 256)
+
+	lossless, err := encodeLossless(img, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	quantized, err := encodeLossless(img, 4)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	if len(quantized) >= len(lossless) {
+		t.Errorf("Expected quantized image smaller than %d bytes, but got %d", len(lossless), len(quantized))
+	}
+
+	if _, err := webp.Decode(bytes.NewReader(quantized)); err != nil {
+		t.Errorf("Expected no decode error, but got %v", err)
+	}
+}